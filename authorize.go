@@ -0,0 +1,266 @@
+package tigasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingRedirectURI = errors.New("redirect_uri is required")
+)
+
+// AuthorizeRequest is the parameters to start an authorization flow at the authorization endpoint.
+type AuthorizeRequest struct {
+	// ResponseType is the space delimited response types. When empty, "code" is used.
+	ResponseType string
+
+	// RedirectURI is the registered redirect uri to receive the authorization response. Required.
+	RedirectURI string
+
+	// Scopes is the list of requested scopes. When empty or nil, ["openid"] is used.
+	Scopes []string
+
+	// ResponseMode is the optional mechanism to return the authorization response.
+	ResponseMode string
+
+	// Display is the optional hint on how to display the authentication and consent pages.
+	Display string
+
+	// Prompt is the optional space delimited list of prompts.
+	Prompt string
+
+	// MaxAge is the optional maximum authentication age in seconds.
+	MaxAge *int64
+
+	// UILocales is the optional space delimited list of preferred locales.
+	UILocales string
+
+	// IdTokenHint is the optional previously issued id token as a hint about the End-User.
+	IdTokenHint string
+
+	// LoginHint is the optional hint about the login identifier of the End-User.
+	LoginHint string
+
+	// AcrValues is the optional space delimited list of requested authentication context class references.
+	AcrValues string
+
+	// Claims is the optional claims request in JSON.
+	Claims json.RawMessage
+
+	// AuthorizationDetails is the optional rich authorization request in JSON, which is an array of
+	// authorization detail objects. Consider PushAuthorizationRequest when it is large.
+	AuthorizationDetails json.RawMessage
+
+	// Extra is any additional parameters to include in the request.
+	Extra map[string]string
+
+	// RequestObject sends all parameters in a request object, signed with the client jwks, in the "request"
+	// parameter. This protects the integrity of the parameters. See WithRequestObjectAlgs for the algorithms.
+	RequestObject bool
+
+	// EncryptRequestObject additionally encrypts the request object to Tiga, which protects the confidentiality
+	// of the parameters. It implies RequestObject.
+	EncryptRequestObject bool
+
+	// DisablePKCE opts out of the automatic PKCE generation when response type includes "code". PKCE is
+	// required for public clients and recommended for all clients, hence should normally be left false.
+	DisablePKCE bool
+}
+
+// AuthorizeSession contains the values generated for an authorize request, which must be persisted by the client,
+// typically in the End-User's session, until the authorization response is received at the redirect uri.
+type AuthorizeSession struct {
+	// State is the opaque value to correlate the authorization response with the request.
+	State string `json:"state"`
+
+	// Nonce is the value to associate the id token with the request. It is only
+	// generated when "openid" scope is requested.
+	Nonce string `json:"nonce,omitempty"`
+
+	// CodeVerifier is the PKCE code verifier to send to TokenByCodeWithPKCE.
+	CodeVerifier string `json:"code_verifier,omitempty"`
+
+	// RedirectURI is the redirect uri used in the request, which must be sent again at token endpoint.
+	RedirectURI string `json:"redirect_uri"`
+
+	// Scopes is the list of requested scopes.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// ResponseMode is the requested response mode, if any.
+	ResponseMode string `json:"response_mode,omitempty"`
+
+	// MaxAge is the requested maximum authentication age, if any, to be checked against "auth_time".
+	MaxAge *int64 `json:"max_age,omitempty"`
+
+	// CreatedAt is the UNIX timestamp when the request was created.
+	CreatedAt int64 `json:"created_at"`
+}
+
+// AuthorizeURL validates the AuthorizeRequest and builds the url to the authorization endpoint to which the
+// End-User should be redirected. The "state", "nonce" and PKCE parameters are generated and returned in the
+// AuthorizeSession, which should be persisted to verify the authorization response.
+//
+// If Tiga requires pushed authorization requests, the request is pushed with PushAuthorizationRequest, and the
+// returned url references the pushed request.
+func (s *SDK) AuthorizeURL(ctx context.Context, req *AuthorizeRequest) (string, *AuthorizeSession, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if discovery.RequirePushedAuthorizationRequests {
+		pushed, session, err := s.PushAuthorizationRequest(ctx, req)
+		if err != nil {
+			return "", nil, err
+		}
+		return pushed.URL, session, nil
+	}
+
+	params, session, err := s.authorizeParams(req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if req.RequestObject || req.EncryptRequestObject {
+		if !discovery.RequestParameterSupportedOrDefault() {
+			return "", nil, ErrRequestObjectNotSupported
+		}
+		if params, err = s.requestObjectParams(ctx, params, req.EncryptRequestObject); err != nil {
+			return "", nil, err
+		}
+	}
+
+	u, err := buildURL(discovery.AuthorizationEndpoint, params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return u, session, nil
+}
+
+// authorizeParams validates the AuthorizeRequest and converts it to the request parameters, generating
+// the secrets to persist along the way.
+func (s *SDK) authorizeParams(req *AuthorizeRequest) (url.Values, *AuthorizeSession, error) {
+	var (
+		responseType = internal.Coalesce(req.ResponseType, oidc.ResponseTypeCode)
+		scopes       = req.Scopes
+	)
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenId}
+	}
+
+	if len(req.RedirectURI) == 0 {
+		return nil, nil, ErrMissingRedirectURI
+	}
+	if err := oidc.ValidCompositeResponseType(responseType); err != nil {
+		return nil, nil, err
+	}
+	if err := oidc.ValidCompositeScope(strings.Join(scopes, " ")); err != nil {
+		return nil, nil, err
+	}
+	if len(req.ResponseMode) > 0 {
+		if err := oidc.ValidResponseMode(req.ResponseMode); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(req.Display) > 0 {
+		if err := oidc.ValidDisplay(req.Display); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(req.Prompt) > 0 {
+		for _, prompt := range strings.Fields(req.Prompt) {
+			if err := oidc.ValidPrompt(prompt); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := oidc.ValidCompositePrompt(req.Prompt)(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	session := &AuthorizeSession{
+		State:        internal.RandomString(16),
+		RedirectURI:  req.RedirectURI,
+		Scopes:       scopes,
+		ResponseMode: req.ResponseMode,
+		MaxAge:       req.MaxAge,
+		CreatedAt:    time.Now().Unix(),
+	}
+
+	params := url.Values{}
+	for k, v := range req.Extra {
+		params.Set(k, v)
+	}
+	params.Set("client_id", s.clientId)
+	params.Set("response_type", responseType)
+	params.Set("redirect_uri", req.RedirectURI)
+	params.Set("scope", strings.Join(scopes, " "))
+	params.Set("state", session.State)
+
+	if internal.NewSet(scopes...).Contains(oidc.ScopeOpenId) {
+		session.Nonce = internal.RandomString(16)
+		params.Set("nonce", session.Nonce)
+	}
+
+	if !req.DisablePKCE && internal.NewSet(strings.Fields(responseType)...).Contains(oidc.ResponseTypeCode) {
+		session.CodeVerifier = oidc.NewCodeVerifier()
+		challenge, err := oidc.CodeChallenge(session.CodeVerifier, oidc.CodeChallengeMethodS256)
+		if err != nil {
+			return nil, nil, err
+		}
+		params.Set("code_challenge", challenge)
+		params.Set("code_challenge_method", oidc.CodeChallengeMethodS256)
+	}
+
+	if s.dpop != nil {
+		jkt, err := s.dpop.thumbprint()
+		if err != nil {
+			return nil, nil, err
+		}
+		params.Set("dpop_jkt", jkt)
+	}
+
+	setIfNotEmpty := func(k, v string) {
+		if len(v) > 0 {
+			params.Set(k, v)
+		}
+	}
+	setIfNotEmpty("response_mode", req.ResponseMode)
+	setIfNotEmpty("display", req.Display)
+	setIfNotEmpty("prompt", req.Prompt)
+	setIfNotEmpty("ui_locales", req.UILocales)
+	setIfNotEmpty("id_token_hint", req.IdTokenHint)
+	setIfNotEmpty("login_hint", req.LoginHint)
+	setIfNotEmpty("acr_values", req.AcrValues)
+	setIfNotEmpty("claims", string(req.Claims))
+	setIfNotEmpty("authorization_details", string(req.AuthorizationDetails))
+	if req.MaxAge != nil {
+		params.Set("max_age", strconv.FormatInt(*req.MaxAge, 10))
+	}
+
+	return params, session, nil
+}
+
+// buildURL appends the parameters to the query of the endpoint url, preserving any existing query.
+func buildURL(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,148 @@
+package tigasdk_test
+
+import (
+	"context"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestSDK_AuthorizeURL(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), nil)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+	)
+	assert.NoError(t, err)
+
+	t.Run("valid request", func(t *testing.T) {
+		maxAge := int64(300)
+		raw, session, err := sdk.AuthorizeURL(context.Background(), &tigasdk.AuthorizeRequest{
+			RedirectURI: "https://client.test/callback",
+			Scopes:      []string{oidc.ScopeOpenId, oidc.ScopeProfile},
+			Prompt:      "login consent",
+			MaxAge:      &maxAge,
+		})
+		assert.NoError(t, err)
+
+		u, err := url.Parse(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, srv.URL+"/oauth/authorize", u.Scheme+"://"+u.Host+u.Path)
+
+		q := u.Query()
+		assert.Equal(t, "client", q.Get("client_id"))
+		assert.Equal(t, "code", q.Get("response_type"))
+		assert.Equal(t, "openid profile", q.Get("scope"))
+		assert.Equal(t, "300", q.Get("max_age"))
+		assert.Equal(t, session.State, q.Get("state"))
+		assert.Equal(t, session.Nonce, q.Get("nonce"))
+
+		challenge, _ := oidc.CodeChallenge(session.CodeVerifier, oidc.CodeChallengeMethodS256)
+		assert.Equal(t, challenge, q.Get("code_challenge"))
+		assert.Equal(t, oidc.CodeChallengeMethodS256, q.Get("code_challenge_method"))
+	})
+
+	t.Run("invalid prompt", func(t *testing.T) {
+		_, _, err := sdk.AuthorizeURL(context.Background(), &tigasdk.AuthorizeRequest{
+			RedirectURI: "https://client.test/callback",
+			Prompt:      "none login",
+		})
+		assert.Equal(t, oidc.ErrInvalidPrompt, err)
+	})
+}
+
+func TestSDK_AuthorizeURLWithRequestObject(t *testing.T) {
+	var (
+		tigaJwks   = jwx.NewKeySet(jwx.GenerateEncryptionKey("enc", jwx.ECDH_ES_A128KW, 0))
+		clientJwks = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+	)
+
+	srv := newTigaServer(tigaJwks, nil)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+		tigasdk.WithClientJwks(clientJwks),
+	)
+	assert.NoError(t, err)
+
+	maxAge := int64(300)
+	raw, session, err := sdk.AuthorizeURL(context.Background(), &tigasdk.AuthorizeRequest{
+		RedirectURI:          "https://client.test/callback",
+		MaxAge:               &maxAge,
+		Claims:               []byte(`{"id_token":{"acr":{"essential":true}}}`),
+		EncryptRequestObject: true,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u, err := url.Parse(raw)
+	assert.NoError(t, err)
+
+	q := u.Query()
+	assert.Equal(t, "client", q.Get("client_id"))
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "openid", q.Get("scope"))
+	assert.Empty(t, q.Get("state"))
+
+	header, err := jwx.ReadHeader(q.Get("request"))
+	assert.NoError(t, err)
+	assert.Equal(t, jwx.ECDH_ES_A128KW, header.Alg)
+	assert.Equal(t, jwx.A128CBC_HS256, header.Enc)
+
+	var claims map[string]interface{}
+	err = jwx.Decode(q.Get("request"), clientJwks.ToPublic(), tigaJwks, jwx.Algs{
+		Sig:     jwx.ES256,
+		Encrypt: jwx.ECDH_ES_A128KW,
+		Encode:  jwx.A128CBC_HS256,
+	}, &claims)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "client", claims["iss"])
+		assert.Equal(t, srv.URL, claims["aud"])
+		assert.Equal(t, session.State, claims["state"])
+		assert.Equal(t, session.Nonce, claims["nonce"])
+		assert.Equal(t, "https://client.test/callback", claims["redirect_uri"])
+		assert.Equal(t, float64(300), claims["max_age"])
+		assert.Equal(t, map[string]interface{}{
+			"id_token": map[string]interface{}{"acr": map[string]interface{}{"essential": true}},
+		}, claims["claims"])
+	}
+
+	t.Run("configured algorithms", func(t *testing.T) {
+		for _, c := range []struct {
+			name string
+			algs [3]string
+			err  error
+		}{
+			{name: "signing alg without key", algs: [3]string{jwx.RS256, "", ""}, err: jwx.ErrNoSigningKey},
+			{name: "unsupported signing alg", algs: [3]string{jwx.ES384, "", ""}, err: jwx.ErrInvalidSignatureAlg},
+			{name: "encryption alg without key", algs: [3]string{"", jwx.RSA_OAEP_256, ""}, err: jwx.ErrNoEncryptionKey},
+			{name: "unsupported encryption alg", algs: [3]string{"", jwx.A256GCMKW, ""}, err: tigasdk.ErrUnsupportedRequestObjectAlg},
+			{name: "unsupported content encryption", algs: [3]string{"", jwx.ECDH_ES_A128KW, jwx.A256GCM}, err: tigasdk.ErrUnsupportedRequestObjectAlg},
+			{name: "supported", algs: [3]string{jwx.ES256, jwx.ECDH_ES_A128KW, jwx.A128CBC_HS256}},
+		} {
+			t.Run(c.name, func(t *testing.T) {
+				sdk, err := tigasdk.NewWithContext(context.Background(),
+					tigasdk.WithServiceBaseURL(srv.URL),
+					tigasdk.WithPublicClient("client"),
+					tigasdk.WithClientJwks(clientJwks),
+					tigasdk.WithRequestObjectAlgs(c.algs[0], c.algs[1], c.algs[2]),
+				)
+				assert.NoError(t, err)
+
+				_, _, err = sdk.AuthorizeURL(context.Background(), &tigasdk.AuthorizeRequest{
+					RedirectURI:          "https://client.test/callback",
+					EncryptRequestObject: true,
+				})
+				assert.Equal(t, c.err, err)
+			})
+		}
+	})
+}
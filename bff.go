@@ -0,0 +1,395 @@
+package tigasdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultBFFRefreshBefore    = time.Minute
+	defaultBFFCSRFCookieName   = "tiga_csrf"
+	defaultBFFCSRFHeaderName   = "X-CSRF-Token"
+	defaultBFFRefreshCacheTTL  = 30 * time.Second
+	defaultBFFRefreshCacheSize = 1000
+)
+
+var (
+	ErrMissingUpstream   = errors.New("bff upstream is required")
+	ErrMissingSessions   = errors.New("bff session manager is required")
+	ErrCSRFTokenMismatch = errors.New("csrf token is missing or does not match")
+)
+
+// BFFOpt is the options for NewBFF.
+type BFFOpt struct {
+	// Upstream is the API to proxy requests to. Required.
+	Upstream *url.URL
+
+	// RedirectURI is the registered redirect uri, at which BFF.CallbackHandler is mounted. Required.
+	RedirectURI string
+
+	// Scopes is the list of scopes to request at login. When empty, ["openid"] is used. Include
+	// "offline_access" to receive refresh tokens.
+	Scopes []string
+
+	// Sessions stores the tokens of the End-User in encrypted cookies. Required.
+	Sessions *SessionManager
+
+	// StateStore stores the AuthorizeSession between login and callback. When nil, an in-memory store is used.
+	StateStore StateStore
+
+	// AfterLoginURL is where the End-User is redirected after login. When empty, "/" is used.
+	AfterLoginURL string
+
+	// AfterLogoutURL is where the End-User is redirected after logout. When empty, "/" is used.
+	AfterLogoutURL string
+
+	// PostLogoutRedirectURI is the registered post logout redirect uri, at which BFF.PostLogoutHandler is mounted.
+	// When set, and Tiga has an end session endpoint, the End-User is also logged out at Tiga.
+	PostLogoutRedirectURI string
+
+	// RefreshBefore is how long before its expiry the access token is refreshed. When zero, 1 minute is used.
+	RefreshBefore time.Duration
+
+	// CSRFCookieName is the name of the cookie holding the CSRF token, readable by the front end. When empty,
+	// "tiga_csrf" is used.
+	CSRFCookieName string
+
+	// CSRFHeaderName is the header in which the front end must echo the CSRF token on unsafe requests. When
+	// empty, "X-CSRF-Token" is used.
+	CSRFHeaderName string
+
+	// Leeway is the time skew tolerance when verifying the id token.
+	Leeway time.Duration
+
+	// RenderError renders the response when login, logout or proxying fails. When nil, 401 is written for
+	// missing or expired sessions, 403 for CSRF failures, and 400 for other errors.
+	RenderError func(rw http.ResponseWriter, r *http.Request, err error)
+}
+
+// BFF is a backend-for-frontend, which logs the End-User in, keeps the tokens in a cookie session, and proxies
+// requests from the front end to the upstream API with the access token attached, so that tokens are never
+// exposed to the browser.
+type BFF struct {
+	sdk        *SDK
+	opt        BFFOpt
+	proxy      *httputil.ReverseProxy
+	callback   http.Handler
+	postLogout http.Handler
+	// stateCookie binds the login state to the browser, alike the session cookies
+	stateCookie *StateCookieOpt
+	refreshed   *internal.Cache
+	refreshing  internal.Flight
+}
+
+// NewBFF creates a BFF with the options.
+func (s *SDK) NewBFF(opt *BFFOpt) (*BFF, error) {
+	if opt == nil || opt.Upstream == nil {
+		return nil, ErrMissingUpstream
+	}
+	if opt.Sessions == nil {
+		return nil, ErrMissingSessions
+	}
+	if len(opt.RedirectURI) == 0 {
+		return nil, ErrMissingRedirectURI
+	}
+
+	b := &BFF{
+		sdk:       s,
+		opt:       *opt,
+		proxy:     httputil.NewSingleHostReverseProxy(opt.Upstream),
+		refreshed: internal.NewCache(defaultBFFRefreshCacheSize),
+	}
+	if b.opt.StateStore == nil {
+		b.opt.StateStore = NewMemoryStateStore(0, 0)
+	}
+	if len(b.opt.AfterLoginURL) == 0 {
+		b.opt.AfterLoginURL = "/"
+	}
+	if len(b.opt.AfterLogoutURL) == 0 {
+		b.opt.AfterLogoutURL = "/"
+	}
+	if b.opt.RefreshBefore <= 0 {
+		b.opt.RefreshBefore = defaultBFFRefreshBefore
+	}
+	if len(b.opt.CSRFCookieName) == 0 {
+		b.opt.CSRFCookieName = defaultBFFCSRFCookieName
+	}
+	if len(b.opt.CSRFHeaderName) == 0 {
+		b.opt.CSRFHeaderName = defaultBFFCSRFHeaderName
+	}
+	if b.opt.RenderError == nil {
+		b.opt.RenderError = func(rw http.ResponseWriter, r *http.Request, err error) {
+			switch err {
+			case ErrNoSession, ErrSessionExpired, ErrInvalidSession:
+				rw.WriteHeader(http.StatusUnauthorized)
+			case ErrCSRFTokenMismatch:
+				rw.WriteHeader(http.StatusForbidden)
+			default:
+				rw.WriteHeader(http.StatusBadRequest)
+			}
+		}
+	}
+
+	b.stateCookie = &StateCookieOpt{
+		Path:     opt.Sessions.opt.CookiePath,
+		Domain:   opt.Sessions.opt.CookieDomain,
+		Insecure: opt.Sessions.opt.Insecure,
+	}
+	b.callback = s.CallbackHandler(&CallbackOpt{
+		StateStore:    b.opt.StateStore,
+		StateCookie:   b.stateCookie,
+		Leeway:        b.opt.Leeway,
+		RenderSuccess: b.login,
+		RenderError:   b.opt.RenderError,
+	})
+	b.postLogout = s.PostLogoutHandler(&PostLogoutOpt{
+		StateStore: b.opt.StateStore,
+		RenderSuccess: func(rw http.ResponseWriter, r *http.Request, session *AuthorizeSession) {
+			http.Redirect(rw, r, b.opt.AfterLogoutURL, http.StatusFound)
+		},
+		RenderError: b.opt.RenderError,
+	})
+
+	return b, nil
+}
+
+// LoginHandler redirects the End-User to the authorization endpoint.
+func (b *BFF) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		authorizeURL, session, err := b.sdk.AuthorizeURL(r.Context(), &AuthorizeRequest{
+			RedirectURI: b.opt.RedirectURI,
+			Scopes:      b.opt.Scopes,
+		})
+		if err != nil {
+			b.opt.RenderError(rw, r, err)
+			return
+		}
+
+		if err := b.opt.StateStore.Save(r.Context(), session); err != nil {
+			b.opt.RenderError(rw, r, err)
+			return
+		}
+		SetStateCookie(rw, session.State, b.stateCookie)
+
+		http.Redirect(rw, r, authorizeURL, http.StatusFound)
+	})
+}
+
+// CallbackHandler handles the authorization response at the redirect uri, see SDK.CallbackHandler. On success,
+// the session and the CSRF cookie are set, and the End-User is redirected to AfterLoginURL.
+func (b *BFF) CallbackHandler() http.Handler {
+	return b.callback
+}
+
+// login establishes the session after a successful callback. The CSRF token is kept in the session, and handed to
+// the front end in the CSRF cookie.
+func (b *BFF) login(rw http.ResponseWriter, r *http.Request, result *CallbackResult) {
+	session := NewSession(result.Tokens, result.IdToken)
+	session.CSRFToken = internal.RandomString(32)
+	if err := b.opt.Sessions.Save(rw, r, session); err != nil {
+		b.opt.RenderError(rw, r, err)
+		return
+	}
+
+	csrf := b.opt.Sessions.cookie(b.opt.CSRFCookieName, session.CSRFToken, 0)
+	csrf.HttpOnly = false
+	http.SetCookie(rw, csrf)
+
+	http.Redirect(rw, r, b.opt.AfterLoginURL, http.StatusFound)
+}
+
+// LogoutHandler clears the session and the CSRF cookie, revokes the refresh token if any, and redirects the
+// End-User to AfterLogoutURL. When PostLogoutRedirectURI is set, the End-User is redirected to the end session
+// endpoint of Tiga instead, and returns to AfterLogoutURL via PostLogoutHandler. It only accepts POST requests,
+// which must carry the CSRF token of the session, if any.
+func (b *BFF) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var idToken string
+		if session, err := b.opt.Sessions.Load(rw, r); err == nil {
+			if err := b.checkCSRF(r, session); err != nil {
+				b.opt.RenderError(rw, r, err)
+				return
+			}
+			idToken = session.IdToken
+			if len(session.RefreshToken) > 0 {
+				// best effort, the session is cleared regardless
+				_ = b.sdk.Revoke(r.Context(), session.RefreshToken, oidc.TokenTypeHintRefreshToken)
+			}
+		}
+
+		if err := b.opt.Sessions.Clear(rw, r); err != nil {
+			b.opt.RenderError(rw, r, err)
+			return
+		}
+		http.SetCookie(rw, b.opt.Sessions.cookie(b.opt.CSRFCookieName, "", -1))
+
+		if len(b.opt.PostLogoutRedirectURI) > 0 {
+			logoutURL, session, err := b.sdk.LogoutURL(r.Context(), &LogoutRequest{
+				IdTokenHint:           idToken,
+				PostLogoutRedirectURI: b.opt.PostLogoutRedirectURI,
+			})
+			switch err {
+			case nil:
+				if err := b.opt.StateStore.Save(r.Context(), session); err != nil {
+					b.opt.RenderError(rw, r, err)
+					return
+				}
+				http.Redirect(rw, r, logoutURL, http.StatusFound)
+				return
+			case ErrEndSessionNotSupported:
+			default:
+				b.opt.RenderError(rw, r, err)
+				return
+			}
+		}
+
+		http.Redirect(rw, r, b.opt.AfterLogoutURL, http.StatusFound)
+	})
+}
+
+// PostLogoutHandler handles the return from the end session endpoint at PostLogoutRedirectURI, see
+// SDK.PostLogoutHandler. On success, the End-User is redirected to AfterLogoutURL.
+func (b *BFF) PostLogoutHandler() http.Handler {
+	return b.postLogout
+}
+
+// ProxyHandler proxies requests to the upstream API with the access token of the session attached. The access
+// token is refreshed with TokenByRefreshToken when it expires within RefreshBefore. Requests with unsafe methods
+// must carry the CSRF token of the session in the CSRFHeaderName header. Cookies are not forwarded upstream.
+func (b *BFF) ProxyHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		session, err := b.opt.Sessions.Load(rw, r)
+		if err != nil {
+			b.opt.RenderError(rw, r, err)
+			return
+		}
+
+		if err := b.checkCSRF(r, session); err != nil {
+			b.opt.RenderError(rw, r, err)
+			return
+		}
+
+		if session.Expiry > 0 && time.Now().Add(b.opt.RefreshBefore).Unix() >= session.Expiry {
+			if session, err = b.refresh(rw, r, session); err != nil {
+				// the session is intact when the End-User merely went away during the refresh
+				if r.Context().Err() == nil {
+					_ = b.opt.Sessions.Clear(rw, r)
+				}
+				b.opt.RenderError(rw, r, ErrSessionExpired)
+				return
+			}
+		}
+
+		upstream := r.Clone(r.Context())
+		upstream.Header.Del("Cookie")
+		upstream.Header.Del(b.opt.CSRFHeaderName)
+		upstream.Header.Set("Authorization", internal.Coalesce(session.TokenType, AccessTokenType)+" "+session.AccessToken)
+
+		if session.TokenType == DPoPTokenType && b.sdk.dpop != nil {
+			target := *b.opt.Upstream
+			target.Path = singleJoiningSlash(b.opt.Upstream.Path, r.URL.Path)
+			proof, err := b.sdk.dpop.proof(r.Method, &target, session.AccessToken)
+			if err != nil {
+				b.opt.RenderError(rw, r, err)
+				return
+			}
+			upstream.Header.Set(headerDPoP, proof)
+		}
+
+		b.proxy.ServeHTTP(rw, upstream)
+	})
+}
+
+// refresh refreshes the access token of a copy of the session, saves and returns it. Concurrent requests with the
+// same refresh token share one refresh, which is not canceled when any one request goes away, and its result is
+// reused for a short while, so that rotated refresh tokens are not used twice.
+func (b *BFF) refresh(rw http.ResponseWriter, r *http.Request, session *Session) (*Session, error) {
+	if len(session.RefreshToken) == 0 {
+		return nil, ErrSessionExpired
+	}
+
+	sum := sha256.Sum256([]byte(session.RefreshToken))
+	key := hex.EncodeToString(sum[:])
+
+	v, err := b.refreshing.DoContext(r.Context(), key, func() (interface{}, error) {
+		if v, ok := b.refreshed.Get(key); ok {
+			return v, nil
+		}
+
+		ctx, cancel := context.WithTimeout(internal.Detach(r.Context()), tokenFetchTimeout)
+		defer cancel()
+
+		tokens, err := b.sdk.TokenByRefreshToken(ctx, session.RefreshToken, nil)
+		if err != nil {
+			return nil, err
+		}
+		b.refreshed.Put(key, tokens, time.Now().Add(defaultBFFRefreshCacheTTL))
+		return tokens, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := v.(*TokenResponse)
+	session = session.copy()
+	session.AccessToken = tokens.AccessToken
+	session.TokenType = tokens.TokenType
+	session.RefreshToken = internal.Coalesce(tokens.RefreshToken, session.RefreshToken)
+	session.IdToken = internal.Coalesce(tokens.IdToken, session.IdToken)
+	session.Expiry = 0
+	if tokens.ExpiresIn != nil {
+		session.Expiry = time.Now().Add(time.Duration(*tokens.ExpiresIn) * time.Second).Unix()
+	}
+
+	if err := b.opt.Sessions.Save(rw, r, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// checkCSRF checks the CSRF header against the CSRF token of the session for requests with unsafe methods. The
+// CSRF cookie only hands the token to the front end, and is not trusted, as it could be planted by a sibling
+// domain.
+func (b *BFF) checkCSRF(r *http.Request, session *Session) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+
+	if len(session.CSRFToken) == 0 {
+		return ErrCSRFTokenMismatch
+	}
+
+	header := r.Header.Get(b.opt.CSRFHeaderName)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(session.CSRFToken)) != 1 {
+		return ErrCSRFTokenMismatch
+	}
+
+	return nil
+}
+
+// singleJoiningSlash joins the paths as httputil.NewSingleHostReverseProxy does.
+func singleJoiningSlash(a, b string) string {
+	switch aslash, bslash := len(a) > 0 && a[len(a)-1] == '/', len(b) > 0 && b[0] == '/'; {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
@@ -0,0 +1,194 @@
+package tigasdk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBFF(t *testing.T) {
+	var (
+		tigaJwks = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		srv      *httptest.Server
+		nonce    atomic.Value
+		revoked  int32
+	)
+
+	srv = newTigaServer(tigaJwks, map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			var resp *tigasdk.TokenResponse
+			switch r.PostFormValue("grant_type") {
+			case "authorization_code":
+				idToken, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, map[string]interface{}{
+					"iss":   srv.URL,
+					"sub":   "user",
+					"aud":   "client",
+					"exp":   time.Now().Add(time.Minute).Unix(),
+					"iat":   time.Now().Unix(),
+					"nonce": nonce.Load(),
+				})
+				assert.NoError(t, err)
+				expiresIn := int64(30)
+				resp = &tigasdk.TokenResponse{AccessToken: "at1", TokenType: "Bearer", ExpiresIn: &expiresIn, RefreshToken: "rt1", IdToken: idToken}
+			case "refresh_token":
+				assert.Equal(t, "rt1", r.PostFormValue("refresh_token"))
+				time.Sleep(100 * time.Millisecond)
+				expiresIn := int64(3600)
+				resp = &tigasdk.TokenResponse{AccessToken: "at2", TokenType: "Bearer", ExpiresIn: &expiresIn, RefreshToken: "rt2"}
+			}
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(resp)
+		},
+		"/oauth/revoke": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "rt2", r.PostFormValue("token"))
+			atomic.AddInt32(&revoked, 1)
+		},
+	})
+	defer srv.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Cookie"))
+		assert.Empty(t, r.Header.Get("X-CSRF-Token"))
+		_, _ = rw.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	defer api.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+	)
+	assert.NoError(t, err)
+
+	sessions, err := tigasdk.NewSessionManager(&tigasdk.SessionOpt{
+		Keys:         jwx.NewKeySet(jwx.NewSymmetricKey("k1", jwx.A256GCMKW, jwx.UseEnc, bytes.Repeat([]byte{1}, 32))),
+		PrimaryKeyId: "k1",
+	})
+	assert.NoError(t, err)
+
+	upstream, _ := url.Parse(api.URL)
+	bff, err := sdk.NewBFF(&tigasdk.BFFOpt{
+		Upstream:    upstream,
+		RedirectURI: "https://client.test/callback",
+		Sessions:    sessions,
+	})
+	assert.NoError(t, err)
+
+	jar := map[string]*http.Cookie{}
+	serve := func(h http.Handler, method string, target string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		for _, c := range jar {
+			r.AddCookie(c)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		for _, c := range rw.Result().Cookies() {
+			if c.MaxAge < 0 {
+				delete(jar, c.Name)
+			} else {
+				jar[c.Name] = c
+			}
+		}
+		return rw
+	}
+
+	// login state issued to the attacker's browser is rejected in the End-User's browser
+	attacker := httptest.NewRecorder()
+	bff.LoginHandler().ServeHTTP(attacker, httptest.NewRequest(http.MethodGet, "/login", nil))
+	attackerLocation, err := url.Parse(attacker.Header().Get("Location"))
+	assert.NoError(t, err)
+	rw := serve(bff.CallbackHandler(), http.MethodGet, "/callback?code=attacker&state="+attackerLocation.Query().Get("state"), nil)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.NotContains(t, jar, "tiga_session")
+
+	// login and callback
+	rw = serve(bff.LoginHandler(), http.MethodGet, "/login", nil)
+	assert.Equal(t, http.StatusFound, rw.Code)
+	location, err := url.Parse(rw.Header().Get("Location"))
+	assert.NoError(t, err)
+	nonce.Store(location.Query().Get("nonce"))
+
+	rw = serve(bff.CallbackHandler(), http.MethodGet, "/callback?code=code&state="+location.Query().Get("state"), nil)
+	assert.Equal(t, http.StatusFound, rw.Code)
+	if assert.Contains(t, jar, "tiga_session") && assert.Contains(t, jar, "tiga_csrf") {
+		assert.False(t, jar["tiga_csrf"].HttpOnly)
+	}
+
+	// access token expiring within a minute is refreshed, even when the request leading the refresh goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(ctx)
+	for _, c := range jar {
+		leader.AddCookie(c)
+	}
+	led := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rw := httptest.NewRecorder()
+		bff.ProxyHandler().ServeHTTP(rw, leader)
+		led <- rw
+	}()
+	time.Sleep(20 * time.Millisecond)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	rw = serve(bff.ProxyHandler(), http.MethodGet, "/orders", nil)
+	assert.Equal(t, "GET /orders Bearer at2", rw.Body.String())
+	if rw := <-led; assert.Equal(t, http.StatusUnauthorized, rw.Code) {
+		assert.Empty(t, rw.Result().Cookies())
+	}
+
+	rw = serve(bff.ProxyHandler(), http.MethodPost, "/orders", nil)
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+
+	rw = serve(bff.ProxyHandler(), http.MethodPost, "/orders", http.Header{"X-Csrf-Token": {jar["tiga_csrf"].Value}})
+	assert.Equal(t, "POST /orders Bearer at2", rw.Body.String())
+
+	// a CSRF cookie planted by a sibling domain does not match the token of the session
+	csrf := jar["tiga_csrf"]
+	jar["tiga_csrf"] = &http.Cookie{Name: "tiga_csrf", Value: "planted"}
+	rw = serve(bff.ProxyHandler(), http.MethodPost, "/orders", http.Header{"X-Csrf-Token": {"planted"}})
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	jar["tiga_csrf"] = csrf
+
+	// logout
+	rw = serve(bff.LogoutHandler(), http.MethodPost, "/logout", http.Header{"X-Csrf-Token": {jar["tiga_csrf"].Value}})
+	assert.Equal(t, http.StatusFound, rw.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&revoked))
+	assert.NotContains(t, jar, "tiga_session")
+	assert.NotContains(t, jar, "tiga_csrf")
+
+	rw = serve(bff.ProxyHandler(), http.MethodGet, "/orders", nil)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+
+	t.Run("logout at tiga", func(t *testing.T) {
+		bff, err := sdk.NewBFF(&tigasdk.BFFOpt{
+			Upstream:              upstream,
+			RedirectURI:           "https://client.test/callback",
+			PostLogoutRedirectURI: "https://client.test/logged_out",
+			Sessions:              sessions,
+		})
+		assert.NoError(t, err)
+
+		// without a session, there is nothing to forge
+		rw := serve(bff.LogoutHandler(), http.MethodPost, "/logout", nil)
+		assert.Equal(t, http.StatusFound, rw.Code)
+
+		location, err := url.Parse(rw.Header().Get("Location"))
+		assert.NoError(t, err)
+		assert.Equal(t, "/oauth/logout", location.Path)
+		assert.Equal(t, "https://client.test/logged_out", location.Query().Get("post_logout_redirect_uri"))
+
+		rw = serve(bff.PostLogoutHandler(), http.MethodGet, "/logged_out?state="+location.Query().Get("state"), nil)
+		assert.Equal(t, http.StatusFound, rw.Code)
+		assert.Equal(t, "/", rw.Header().Get("Location"))
+	})
+}
@@ -0,0 +1,138 @@
+package tigasdk
+
+import (
+	"context"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"time"
+)
+
+const (
+	defaultBootstrapAttempts = 3
+	defaultBootstrapBackoff  = 1 * time.Second
+	bootstrapFetchTimeout    = 10 * time.Second
+)
+
+// Ready returns nil if the sdk has fetched the discovery document and the Tiga jwks. If the sdk was created with
+// WithLazyBootstrap and has not yet done so, it attempts the bootstrap with the given context, and returns
+// any error encountered. It is suitable to be used in readiness checks.
+func (s *SDK) Ready(ctx context.Context) error {
+	return s.bootstrap(ctx)
+}
+
+// bootstrap fetches the discovery document and the Tiga jwks, if they had not been fetched yet. Each fetch
+// is retried with exponential backoff according to the bootstrap settings. Concurrent callers share a single
+// bootstrap, which runs without any lock held and is not canceled by any one caller; each caller stops waiting
+// as soon as its own ctx is done.
+func (s *SDK) bootstrap(ctx context.Context) error {
+	if s.bootstrapped() {
+		return nil
+	}
+
+	_, err := s.bootstrapFlight.DoContext(ctx, "bootstrap", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(internal.Detach(ctx), s.bootstrapTimeout())
+		defer cancel()
+
+		s.bootstrapMu.RLock()
+		hasDiscovery := s.discovery != nil
+		s.bootstrapMu.RUnlock()
+
+		if !hasDiscovery {
+			var d *oidc.Discovery
+			if err := s.retry(ctx, func() (err error) {
+				d, err = s.fetchDiscovery(ctx)
+				return
+			}); err != nil {
+				return nil, err
+			}
+
+			s.bootstrapMu.Lock()
+			if s.discovery == nil {
+				s.discovery = d
+			}
+			s.bootstrapMu.Unlock()
+		}
+
+		if s.tigaJwks.current() == nil {
+			if err := s.retry(ctx, func() error {
+				return s.tigaJwks.refresh(ctx, true)
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	})
+
+	return err
+}
+
+// bootstrapped returns true if both the discovery document and the Tiga jwks have been fetched.
+func (s *SDK) bootstrapped() bool {
+	s.bootstrapMu.RLock()
+	defer s.bootstrapMu.RUnlock()
+	return s.discovery != nil && s.tigaJwks.current() != nil
+}
+
+// bootstrapTimeout is the upper bound of a bootstrap, which allows every attempt of both fetches to take up to
+// bootstrapFetchTimeout, plus the backoff in between.
+func (s *SDK) bootstrapTimeout() time.Duration {
+	var (
+		total   time.Duration
+		backoff = s.bootstrapBackoff
+	)
+	for i := 0; i < s.bootstrapAttempts; i++ {
+		total += bootstrapFetchTimeout
+		if i > 0 {
+			total += backoff
+			backoff *= 2
+		}
+	}
+	return 2 * total
+}
+
+// getDiscovery returns the oidc.Discovery, bootstrapping the sdk if necessary.
+func (s *SDK) getDiscovery(ctx context.Context) (*oidc.Discovery, error) {
+	if err := s.bootstrap(ctx); err != nil {
+		return nil, err
+	}
+
+	s.bootstrapMu.RLock()
+	defer s.bootstrapMu.RUnlock()
+
+	return s.discovery, nil
+}
+
+// getTigaJwks returns the Tiga jwx.KeySet, bootstrapping the sdk if necessary.
+func (s *SDK) getTigaJwks(ctx context.Context) (*jwx.KeySet, error) {
+	if err := s.bootstrap(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.tigaJwks.current(), nil
+}
+
+func (s *SDK) retry(ctx context.Context, fn func() error) error {
+	var (
+		err     error
+		backoff = s.bootstrapBackoff
+	)
+
+	for i := 0; i < s.bootstrapAttempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+				backoff *= 2
+			}
+		}
+
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
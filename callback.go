@@ -0,0 +1,223 @@
+package tigasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	ErrEmptyAuthorizeResponse = errors.New("authorization response is empty")
+	ErrInvalidAuthorizeSigAlg = errors.New("authorization response signing algorithm is not supported")
+	ErrIssuerMismatch         = errors.New("authorization response iss parameter does not match issuer")
+)
+
+// AuthorizeResponse is the authorization response delivered to the redirect uri.
+type AuthorizeResponse struct {
+	Code             string
+	State            string
+	Issuer           string
+	IdToken          string
+	AccessToken      string
+	TokenType        string
+	ExpiresIn        string
+	Scope            string
+	Error            string
+	ErrorDescription string
+	ErrorURI         string
+
+	// Secured is true when the response was delivered as a JWT, see ParseAuthorizeResponse. Clients that request
+	// a JWT response mode must reject responses that are not Secured, as a downgrade to a plain response bypasses
+	// the signature of Tiga.
+	Secured bool
+}
+
+// Err returns the error carried by the authorization response as ErrorResponse, or nil if the response is
+// successful.
+func (r *AuthorizeResponse) Err() error {
+	if len(r.Error) == 0 {
+		return nil
+	}
+	return &ErrorResponse{Code: r.Error, Reason: r.ErrorDescription}
+}
+
+// ParseAuthorizeResponse reads the authorization response from the query of GET requests (query and query.jwt
+// modes), or from the form body of POST requests (form_post and form_post.jwt modes, as well as the fragment
+// relayed by the page written by WriteFragmentRelay).
+//
+// When the response is delivered as a JWT in the "response" parameter, it is decrypted with keys set by
+// WithClientJwks if encrypted, verified against the Tiga jwks, and its "iss", "aud" and "exp" claims are
+// validated before the parameters are read from it. Otherwise, the "iss" parameter, if present, must match the
+// issuer.
+//
+// ErrEmptyAuthorizeResponse is returned when the request carries no response parameters, which, for GET
+// requests, usually indicates the response was returned in the fragment.
+func (s *SDK) ParseAuthorizeResponse(r *http.Request) (*AuthorizeResponse, error) {
+	var params url.Values
+	switch r.Method {
+	case http.MethodGet:
+		params = r.URL.Query()
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		params = r.PostForm
+	default:
+		return nil, ErrEmptyAuthorizeResponse
+	}
+
+	discovery, err := s.getDiscovery(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	if token := params.Get("response"); len(token) > 0 {
+		claims, err := s.decodeAuthorizeResponseJwt(r.Context(), token)
+		if err != nil {
+			return nil, err
+		}
+
+		params = url.Values{}
+		for k, v := range claims {
+			switch v := v.(type) {
+			case string:
+				params.Set(k, v)
+			case float64, bool:
+				params.Set(k, fmt.Sprint(v))
+			}
+		}
+
+		resp := newAuthorizeResponse(params)
+		resp.Secured = true
+		return resp, nil
+	}
+
+	if len(params.Get("code")) == 0 && len(params.Get("error")) == 0 &&
+		len(params.Get("id_token")) == 0 && len(params.Get("access_token")) == 0 {
+		return nil, ErrEmptyAuthorizeResponse
+	}
+
+	if iss := params.Get("iss"); len(iss) > 0 && iss != discovery.Issuer {
+		return nil, ErrIssuerMismatch
+	}
+
+	return newAuthorizeResponse(params), nil
+}
+
+// decodeAuthorizeResponseJwt decrypts and/or verifies the JARM response, validates the standard claims, and
+// returns all claims.
+func (s *SDK) decodeAuthorizeResponseJwt(ctx context.Context, token string) (map[string]interface{}, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hd, err := jwx.ReadHeader(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if hd.IsEncrypted() {
+		decrypted, err := jwx.Decrypt(token, s.clientJwks)
+		if err != nil {
+			return nil, err
+		}
+
+		token = string(decrypted)
+		if hd, err = jwx.ReadHeader(token); err != nil {
+			return nil, err
+		}
+	}
+
+	if jwx.IsNone(hd.Alg) {
+		return nil, ErrInvalidAuthorizeSigAlg
+	}
+	if supported := discovery.AuthorizationSigningAlgValuesSupported; len(supported) > 0 && !internal.NewSet(supported...).Contains(hd.Alg) {
+		return nil, ErrInvalidAuthorizeSigAlg
+	}
+
+	var payload json.RawMessage
+	if err := s.decode(ctx, token, nil, jwx.Algs{Sig: hd.Alg}, &payload); err != nil {
+		return nil, err
+	}
+
+	std := new(jwt.Claims)
+	if err := json.Unmarshal(payload, std); err != nil {
+		return nil, err
+	}
+	if std.Expiry == nil {
+		return nil, jwx.ErrExpExpired
+	}
+	if err := jwx.ValidateClaims(jwx.NewJWTClaims(*std),
+		jwx.ExpectIss(discovery.Issuer),
+		jwx.ExpectAud(s.clientId),
+		jwx.ExpectTime(0),
+	); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func newAuthorizeResponse(params url.Values) *AuthorizeResponse {
+	return &AuthorizeResponse{
+		Code:             params.Get("code"),
+		State:            params.Get("state"),
+		Issuer:           params.Get("iss"),
+		IdToken:          params.Get("id_token"),
+		AccessToken:      params.Get("access_token"),
+		TokenType:        params.Get("token_type"),
+		ExpiresIn:        params.Get("expires_in"),
+		Scope:            params.Get("scope"),
+		Error:            params.Get("error"),
+		ErrorDescription: params.Get("error_description"),
+		ErrorURI:         params.Get("error_uri"),
+	}
+}
+
+// fragmentRelayPage posts the parameters in the fragment, if any, back to the current url as a form.
+const fragmentRelayPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Signing in...</title></head>
+<body>
+<noscript>JavaScript is required to complete sign in.</noscript>
+<script>
+(function () {
+  var form = document.createElement("form");
+  form.method = "POST";
+  form.action = window.location.pathname + window.location.search;
+  new URLSearchParams(window.location.hash.substring(1)).forEach(function (value, name) {
+    var input = document.createElement("input");
+    input.type = "hidden";
+    input.name = name;
+    input.value = value;
+    form.appendChild(input);
+  });
+  document.body.appendChild(form);
+  form.submit();
+})();
+</script>
+</body>
+</html>`
+
+// WriteFragmentRelay writes a page which relays the authorization response in the fragment (fragment and
+// fragment.jwt modes), invisible to the server, back to the same url as a form post, so that it can be read by
+// ParseAuthorizeResponse.
+func WriteFragmentRelay(rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Referrer-Policy", "no-referrer")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte(fragmentRelayPage))
+}
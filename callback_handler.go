@@ -0,0 +1,159 @@
+package tigasdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCodeReplayCacheSize = 10000
+	codeReplayWindow           = 10 * time.Minute
+)
+
+var (
+	ErrMissingState                = errors.New("authorization response has no state")
+	ErrMissingCode                 = errors.New("authorization response has no code")
+	ErrAuthorizeResponseNotSecured = errors.New("authorization response is expected to be a JWT")
+	ErrCodeReplayed                = errors.New("authorization code has been used before")
+)
+
+// CallbackOpt is the options for CallbackHandler.
+type CallbackOpt struct {
+	// StateStore loads the AuthorizeSession saved before redirecting to the authorization endpoint. It is required.
+	StateStore StateStore
+
+	// StateCookie is the options of the cookie set by SetStateCookie before redirecting to the authorization
+	// endpoint. When nil, the default options are used.
+	StateCookie *StateCookieOpt
+
+	// Leeway is the time skew tolerance when verifying the id token.
+	Leeway time.Duration
+
+	// RenderSuccess renders the response after the code is exchanged and the id token, if any, is verified. It
+	// is where the login session is established. When nil, the End-User is redirected to "/".
+	RenderSuccess func(rw http.ResponseWriter, r *http.Request, result *CallbackResult)
+
+	// RenderError renders the response when the authorization response is an error, or fails validation. When
+	// nil, a 400 status is written.
+	RenderError func(rw http.ResponseWriter, r *http.Request, err error)
+}
+
+// CallbackResult is the outcome of a successful authorization callback.
+type CallbackResult struct {
+	// Session is the AuthorizeSession of the request.
+	Session *AuthorizeSession
+
+	// Tokens is the token response of the exchanged code.
+	Tokens *TokenResponse
+
+	// IdToken is the verified id token claims, or nil if no id token was issued.
+	IdToken *IdTokenClaims
+}
+
+// CallbackHandler returns a http.Handler for the redirect uri, which reads the authorization response with
+// ParseAuthorizeResponse (relaying the fragment when necessary), takes the AuthorizeSession of its state from the
+// StateStore, exchanges the code with TokenByCodeWithPKCE, and verifies the id token with VerifyIdToken.
+//
+// Responses without state, or with a state not found in the StateStore, are rejected with ErrMissingState and
+// ErrStateNotFound. The state must also be bound to the user agent by the cookie set with SetStateCookie, otherwise
+// the response is rejected with ErrStateNotBound before the session is taken. Each code is only accepted once by
+// this handler, replays are rejected with ErrCodeReplayed. When the session requested a JWT response mode,
+// responses not delivered as a JWT are rejected. Error responses from Tiga are rendered as ErrorResponse.
+func (s *SDK) CallbackHandler(opt *CallbackOpt) http.Handler {
+	if opt == nil || opt.StateStore == nil {
+		panic("tigasdk: CallbackOpt.StateStore is required")
+	}
+
+	if opt.RenderSuccess == nil {
+		opt.RenderSuccess = func(rw http.ResponseWriter, r *http.Request, result *CallbackResult) {
+			http.Redirect(rw, r, "/", http.StatusFound)
+		}
+	}
+	if opt.RenderError == nil {
+		opt.RenderError = func(rw http.ResponseWriter, r *http.Request, err error) {
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}
+
+	replay := internal.NewCache(defaultCodeReplayCacheSize)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		resp, err := s.ParseAuthorizeResponse(r)
+		if err == ErrEmptyAuthorizeResponse && r.Method == http.MethodGet {
+			WriteFragmentRelay(rw)
+			return
+		} else if err != nil {
+			opt.RenderError(rw, r, err)
+			return
+		}
+
+		if len(resp.State) == 0 {
+			opt.RenderError(rw, r, ErrMissingState)
+			return
+		}
+
+		if err := checkStateCookie(rw, r, resp.State, opt.StateCookie); err != nil {
+			opt.RenderError(rw, r, err)
+			return
+		}
+
+		session, err := opt.StateStore.Take(r.Context(), resp.State)
+		if err != nil {
+			opt.RenderError(rw, r, err)
+			return
+		}
+
+		if strings.HasSuffix(session.ResponseMode, oidc.ResponseModeJwt) && !resp.Secured {
+			opt.RenderError(rw, r, ErrAuthorizeResponseNotSecured)
+			return
+		}
+
+		if err := resp.Err(); err != nil {
+			opt.RenderError(rw, r, err)
+			return
+		}
+
+		if len(resp.Code) == 0 {
+			opt.RenderError(rw, r, ErrMissingCode)
+			return
+		}
+
+		sum := sha256.Sum256([]byte(resp.Code))
+		if !replay.Add(hex.EncodeToString(sum[:]), true, time.Now().Add(codeReplayWindow)) {
+			opt.RenderError(rw, r, ErrCodeReplayed)
+			return
+		}
+
+		tokens, err := s.TokenByCodeWithPKCE(r.Context(), resp.Code, session.RedirectURI, session.CodeVerifier, nil)
+		if err != nil {
+			opt.RenderError(rw, r, err)
+			return
+		}
+
+		result := &CallbackResult{Session: session, Tokens: tokens}
+
+		if len(tokens.IdToken) > 0 {
+			result.IdToken, err = s.VerifyIdToken(r.Context(), tokens.IdToken, &IdTokenOpt{
+				Nonce:       session.Nonce,
+				MaxAge:      session.MaxAge,
+				AccessToken: tokens.AccessToken,
+				Leeway:      opt.Leeway,
+			})
+			if err != nil {
+				opt.RenderError(rw, r, err)
+				return
+			}
+		} else if len(session.Nonce) > 0 {
+			opt.RenderError(rw, r, ErrInvalidIdToken)
+			return
+		}
+
+		opt.RenderSuccess(rw, r, result)
+	})
+}
@@ -0,0 +1,171 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSDK_CallbackHandler(t *testing.T) {
+	var (
+		tigaJwks = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		srv      *httptest.Server
+	)
+
+	srv = newTigaServer(tigaJwks, map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "verifier", r.PostFormValue("code_verifier"))
+			idToken, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, map[string]interface{}{
+				"iss":   srv.URL,
+				"sub":   "user",
+				"aud":   "client",
+				"exp":   time.Now().Add(time.Minute).Unix(),
+				"iat":   time.Now().Unix(),
+				"nonce": "nonce",
+			})
+			assert.NoError(t, err)
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer", IdToken: idToken})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+	)
+	assert.NoError(t, err)
+
+	var (
+		store   = tigasdk.NewMemoryStateStore(0, 0)
+		result  *tigasdk.CallbackResult
+		failure error
+	)
+	handler := sdk.CallbackHandler(&tigasdk.CallbackOpt{
+		StateStore: store,
+		RenderSuccess: func(rw http.ResponseWriter, r *http.Request, res *tigasdk.CallbackResult) {
+			result = res
+		},
+		RenderError: func(rw http.ResponseWriter, r *http.Request, err error) {
+			failure = err
+		},
+	})
+
+	// browser keeps the state cookies of the End-User
+	browser := map[string]*http.Cookie{}
+	bind := func(state string) {
+		rw := httptest.NewRecorder()
+		tigasdk.SetStateCookie(rw, state, nil)
+		for _, c := range rw.Result().Cookies() {
+			assert.True(t, c.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+			assert.NotContains(t, c.Value, state)
+			browser[c.Name] = c
+		}
+	}
+
+	save := func(state string) {
+		assert.NoError(t, store.Save(context.Background(), &tigasdk.AuthorizeSession{
+			State:        state,
+			Nonce:        "nonce",
+			CodeVerifier: "verifier",
+			RedirectURI:  "https://client.test/callback",
+			CreatedAt:    time.Now().Unix(),
+		}))
+		bind(state)
+	}
+
+	serveIn := func(cookies map[string]*http.Cookie, target string) {
+		result, failure = nil, nil
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, r)
+		for _, c := range rw.Result().Cookies() {
+			if _, ok := cookies[c.Name]; ok && c.MaxAge < 0 {
+				delete(cookies, c.Name)
+			}
+		}
+	}
+	serve := func(target string) { serveIn(browser, target) }
+
+	t.Run("success", func(t *testing.T) {
+		save("s1")
+		serve("/callback?code=c1&state=s1")
+		if assert.NoError(t, failure) && assert.NotNil(t, result) {
+			assert.Equal(t, "token", result.Tokens.AccessToken)
+			assert.Equal(t, "user", result.IdToken.Subject)
+			assert.Equal(t, "s1", result.Session.State)
+		}
+	})
+
+	t.Run("state reused", func(t *testing.T) {
+		bind("s1")
+		serve("/callback?code=c2&state=s1")
+		assert.Equal(t, tigasdk.ErrStateNotFound, failure)
+	})
+
+	t.Run("state issued to another browser", func(t *testing.T) {
+		save("s7")
+		serveIn(map[string]*http.Cookie{}, "/callback?code=c7&state=s7")
+		assert.Equal(t, tigasdk.ErrStateNotBound, failure)
+
+		// the session is left for its own browser
+		serve("/callback?code=c7&state=s7")
+		assert.NoError(t, failure)
+		assert.Empty(t, browser)
+	})
+
+	t.Run("state cookie tampered", func(t *testing.T) {
+		save("s8")
+		for _, c := range browser {
+			c.Value = "tampered"
+		}
+		serve("/callback?code=c8&state=s8")
+		assert.Equal(t, tigasdk.ErrStateNotBound, failure)
+	})
+
+	t.Run("missing state", func(t *testing.T) {
+		serve("/callback?code=c3")
+		assert.Equal(t, tigasdk.ErrMissingState, failure)
+	})
+
+	t.Run("code replayed", func(t *testing.T) {
+		save("s4")
+		serve("/callback?code=c1&state=s4")
+		assert.Equal(t, tigasdk.ErrCodeReplayed, failure)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		save("s5")
+		serve("/callback?error=access_denied&error_description=denied&state=s5")
+		assert.Equal(t, &tigasdk.ErrorResponse{Code: "access_denied", Reason: "denied"}, failure)
+	})
+
+	t.Run("jwt response downgraded", func(t *testing.T) {
+		assert.NoError(t, store.Save(context.Background(), &tigasdk.AuthorizeSession{
+			State:        "s6",
+			ResponseMode: oidc.ResponseModeQueryJwt,
+			CreatedAt:    time.Now().Unix(),
+		}))
+		bind("s6")
+		serve("/callback?code=c6&state=s6")
+		assert.Equal(t, tigasdk.ErrAuthorizeResponseNotSecured, failure)
+	})
+
+	t.Run("fragment relay", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/callback", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Contains(t, rw.Body.String(), "window.location.hash")
+	})
+}
@@ -0,0 +1,136 @@
+package tigasdk_test
+
+import (
+	"context"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSDK_ParseAuthorizeResponse(t *testing.T) {
+	var (
+		tigaJwks   = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		clientJwks = jwx.NewKeySet(jwx.GenerateEncryptionKey("enc", jwx.ECDH_ES_A128KW, 0))
+		srv        = newTigaServer(tigaJwks, nil)
+	)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+		tigasdk.WithClientJwks(clientJwks),
+	)
+	assert.NoError(t, err)
+
+	issue := func(encrypt bool, modify func(c map[string]interface{})) string {
+		c := map[string]interface{}{
+			"iss":   srv.URL,
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"code":  "code",
+			"state": "state",
+		}
+		if modify != nil {
+			modify(c)
+		}
+		enc := jwx.SkipKeySource
+		if encrypt {
+			enc = jwx.EncryptionKeyByAlg(jwx.ECDH_ES_A128KW, jwx.A128CBC_HS256, clientJwks)
+		}
+		token, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), enc, c)
+		assert.NoError(t, err)
+		return token
+	}
+
+	post := func(form url.Values) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+
+	t.Run("query", func(t *testing.T) {
+		resp, err := sdk.ParseAuthorizeResponse(httptest.NewRequest(http.MethodGet, "/callback?code=code&state=state&iss="+url.QueryEscape(srv.URL), nil))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "code", resp.Code)
+			assert.Equal(t, "state", resp.State)
+			assert.NoError(t, resp.Err())
+			assert.False(t, resp.Secured)
+		}
+	})
+
+	t.Run("form post error", func(t *testing.T) {
+		resp, err := sdk.ParseAuthorizeResponse(post(url.Values{"error": {"access_denied"}, "state": {"state"}}))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "state", resp.State)
+			assert.Equal(t, &tigasdk.ErrorResponse{Code: "access_denied"}, resp.Err())
+		}
+	})
+
+	t.Run("fragment", func(t *testing.T) {
+		_, err := sdk.ParseAuthorizeResponse(httptest.NewRequest(http.MethodGet, "/callback", nil))
+		assert.Equal(t, tigasdk.ErrEmptyAuthorizeResponse, err)
+
+		rw := httptest.NewRecorder()
+		tigasdk.WriteFragmentRelay(rw)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Contains(t, rw.Body.String(), "window.location.hash")
+	})
+
+	t.Run("mix-up", func(t *testing.T) {
+		_, err := sdk.ParseAuthorizeResponse(httptest.NewRequest(http.MethodGet, "/callback?code=code&iss=https://evil.test", nil))
+		assert.Equal(t, tigasdk.ErrIssuerMismatch, err)
+	})
+
+	t.Run("jwt", func(t *testing.T) {
+		resp, err := sdk.ParseAuthorizeResponse(httptest.NewRequest(http.MethodGet, "/callback?response="+issue(false, nil), nil))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "code", resp.Code)
+			assert.Equal(t, "state", resp.State)
+			assert.Equal(t, srv.URL, resp.Issuer)
+			assert.True(t, resp.Secured)
+		}
+	})
+
+	t.Run("encrypted jwt", func(t *testing.T) {
+		resp, err := sdk.ParseAuthorizeResponse(post(url.Values{"response": {issue(true, nil)}}))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "code", resp.Code)
+		}
+	})
+
+	t.Run("downgrade", func(t *testing.T) {
+		// a plain response replacing the expected JWT response, with parameters that could mimic the JWT claims
+		for _, r := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/callback?code=code&state=state&secured=true", nil),
+			post(url.Values{"code": {"code"}, "state": {"state"}, "response": {""}}),
+		} {
+			resp, err := sdk.ParseAuthorizeResponse(r)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "code", resp.Code)
+				assert.False(t, resp.Secured)
+			}
+		}
+	})
+
+	for _, c := range []struct {
+		name   string
+		modify func(c map[string]interface{})
+		expect error
+	}{
+		{name: "wrong issuer", modify: func(c map[string]interface{}) { c["iss"] = "https://evil.test" }, expect: jwx.ErrInvalidIss},
+		{name: "wrong audience", modify: func(c map[string]interface{}) { c["aud"] = "other" }, expect: jwx.ErrInvalidAud},
+		{name: "expired", modify: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, expect: jwx.ErrExpExpired},
+		{name: "missing exp", modify: func(c map[string]interface{}) { delete(c, "exp") }, expect: jwx.ErrExpExpired},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := sdk.ParseAuthorizeResponse(post(url.Values{"response": {issue(false, c.modify)}}))
+			assert.Equal(t, c.expect, err)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/imulab/coldcall"
+	"github.com/imulab/coldcall/body"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDevicePollInterval = 5 * time.Second
+	deviceSlowDownIncrement   = 5 * time.Second
+)
+
+var (
+	ErrDeviceAuthorizationNotSupported = errors.New("device authorization endpoint is not available")
+	ErrDeviceCodeExpired               = errors.New("device code has expired before the End-User authorized the device")
+)
+
+// DeviceAuthorization is the response object at device authorization endpoint. The client should display the
+// UserCode and VerificationURI (or VerificationURIComplete, e.g. as a QR code) to the End-User, and then call
+// PollDeviceToken to wait for the End-User to authorize the device.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+
+	sdk       *SDK
+	expiresAt time.Time
+}
+
+// DeviceAuthorize requests the device authorization endpoint to start a device authorization flow, for clients
+// that lack a browser or have limited input capabilities, such as command line tools.
+func (s *SDK) DeviceAuthorize(ctx context.Context, scopes []string) (*DeviceAuthorization, error) {
+	discovery, err := s.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(discovery.DeviceAuthorizationEndpoint) == 0 {
+		return nil, ErrDeviceAuthorizationNotSupported
+	}
+
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id": s.clientId,
+		"scope":     strings.Join(scopes, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var successConstructor coldcall.Constructor = func() interface{} { return new(DeviceAuthorization) }
+
+	result, err := s.postForm(ctx, discovery.DeviceAuthorizationEndpoint, options, body.JSONUnmarshal(successConstructor))
+	if err != nil {
+		return nil, err
+	}
+
+	da := result.(*DeviceAuthorization)
+	da.sdk = s
+	da.expiresAt = time.Now().Add(time.Duration(da.ExpiresIn) * time.Second)
+
+	return da, nil
+}
+
+// PollDeviceToken polls the token endpoint with the device code until the End-User authorizes the device, the
+// device code expires, or the context is done. Polls are spaced by Interval, which defaults to 5 seconds and is
+// increased by 5 seconds every time Tiga asks the client to slow down.
+//
+// ErrDeviceCodeExpired is returned when the device code expires. When the End-User denies the request, the
+// *ErrorResponse is returned with the "access_denied" error code.
+func (da *DeviceAuthorization) PollDeviceToken(ctx context.Context) (*TokenResponse, error) {
+	interval := defaultDevicePollInterval
+	if da.Interval > 0 {
+		interval = time.Duration(da.Interval) * time.Second
+	}
+
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if da.ExpiresIn > 0 && time.Now().After(da.expiresAt) {
+			return nil, ErrDeviceCodeExpired
+		}
+
+		tr, err := da.sdk.TokenByDeviceCode(ctx, da.DeviceCode)
+		if err == nil {
+			return tr, nil
+		}
+
+		e, ok := err.(*ErrorResponse)
+		if !ok {
+			return nil, err
+		}
+
+		switch e.Code {
+		case oidc.ErrorCodeAuthorizationPending:
+			continue
+		case oidc.ErrorCodeSlowDown:
+			interval += deviceSlowDownIncrement
+		case oidc.ErrorCodeExpiredToken:
+			return nil, ErrDeviceCodeExpired
+		default:
+			return nil, err
+		}
+	}
+}
+
+// TokenByDeviceCode requests the token endpoint once with the device code. Most clients should use
+// DeviceAuthorization.PollDeviceToken instead.
+func (s *SDK) TokenByDeviceCode(ctx context.Context, deviceCode string) (*TokenResponse, error) {
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":   s.clientId,
+		"grant_type":  oidc.GrantTypeDeviceCode,
+		"device_code": deviceCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeTokenRequest(ctx, options)
+}
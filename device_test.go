@@ -0,0 +1,65 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSDK_DeviceAuthorize(t *testing.T) {
+	var polls int32
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/device_authorization": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "cli", r.PostFormValue("client_id"))
+			assert.Equal(t, "openid offline_access", r.PostFormValue("scope"))
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.DeviceAuthorization{
+				DeviceCode:      "device-code",
+				UserCode:        "WDJB-MJHT",
+				VerificationURI: "https://tiga.test/device",
+				ExpiresIn:       60,
+				Interval:        1,
+			})
+		},
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, oidc.GrantTypeDeviceCode, r.PostFormValue("grant_type"))
+			assert.Equal(t, "device-code", r.PostFormValue("device_code"))
+
+			rw.Header().Set("Content-Type", "application/json")
+			if atomic.AddInt32(&polls, 1) == 1 {
+				rw.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(rw).Encode(&tigasdk.ErrorResponse{Code: oidc.ErrorCodeAuthorizationPending})
+				return
+			}
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "access-token", TokenType: "Bearer"})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("cli"),
+	)
+	assert.NoError(t, err)
+
+	da, err := sdk.DeviceAuthorize(context.Background(), []string{"openid", "offline_access"})
+	assert.NoError(t, err)
+	assert.Equal(t, "WDJB-MJHT", da.UserCode)
+
+	tr, err := da.PollDeviceToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", tr.AccessToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&polls))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = da.PollDeviceToken(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -1,8 +1,103 @@
 package tigasdk
 
-import "github.com/absurdlab/tiga-go-sdk/oidc"
+import (
+	"context"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/imulab/coldcall"
+	"github.com/imulab/coldcall/body"
+	"github.com/imulab/coldcall/status"
+	"reflect"
+	"time"
+)
 
-// Discovery returns a new copy of the underlying internal oidc.Discovery.
+// Discovery returns a new copy of the underlying internal oidc.Discovery. If the sdk was created with
+// WithLazyBootstrap and the discovery document has not been fetched yet, nil is returned.
 func (s *SDK) Discovery() *oidc.Discovery {
+	s.bootstrapMu.RLock()
+	defer s.bootstrapMu.RUnlock()
+
+	if s.discovery == nil {
+		return nil
+	}
+
 	return s.discovery.Clone()
 }
+
+// OnDiscoveryChange registers a function to be called when a refreshed discovery document differs from the
+// previous one. The function receives copies of the old and the new oidc.Discovery. It is called on the
+// background refresh goroutine, hence should return quickly. See WithDiscoveryRefresh.
+func (s *SDK) OnDiscoveryChange(fn func(old, new *oidc.Discovery)) {
+	s.listenerMu.Lock()
+	defer s.listenerMu.Unlock()
+
+	s.discoveryListeners = append(s.discoveryListeners, fn)
+}
+
+// refreshDiscovery fetches the discovery document and replaces the current one. Registered listeners
+// are notified if the document has changed.
+func (s *SDK) refreshDiscovery(ctx context.Context) error {
+	d, err := s.fetchDiscovery(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.bootstrapMu.Lock()
+	old := s.discovery
+	s.discovery = d
+	s.bootstrapMu.Unlock()
+
+	if old == nil || reflect.DeepEqual(old, d) {
+		return nil
+	}
+
+	s.listenerMu.Lock()
+	listeners := make([]func(old, new *oidc.Discovery), len(s.discoveryListeners))
+	copy(listeners, s.discoveryListeners)
+	s.listenerMu.Unlock()
+
+	for _, fn := range listeners {
+		fn(old.Clone(), d.Clone())
+	}
+
+	return nil
+}
+
+// runDiscoveryRefresh refreshes the discovery document every interval until stop is closed. Failed
+// refreshes are ignored and the last known discovery document remains in use.
+func (s *SDK) runDiscoveryRefresh(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_ = s.refreshDiscovery(context.Background())
+		}
+	}
+}
+
+func (s *SDK) fetchDiscovery(ctx context.Context) (*oidc.Discovery, error) {
+	req, err := coldcall.Get(ctx, s.serviceBaseURL+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+
+	var newDiscovery coldcall.Constructor = func() interface{} {
+		return new(oidc.Discovery)
+	}
+
+	d, _, err := coldcall.Response(s.httpClient.Do(req)).
+		Expect(status.Is200, body.JSONUnmarshal(newDiscovery)).
+		Read()
+	if err != nil {
+		return nil, err
+	}
+
+	if discovery, ok := d.(*oidc.Discovery); ok {
+		return discovery, nil
+	}
+
+	return nil, ErrUnexpectedResponse
+}
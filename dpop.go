@@ -0,0 +1,219 @@
+package tigasdk
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DPoPTokenType is the token type of access tokens bound to a DPoP key.
+const DPoPTokenType = "DPoP"
+
+// Modes of DPoP in Protect middleware.
+const (
+	// DPoPDisabled only accepts bearer tokens, the "cnf" claim is not checked.
+	DPoPDisabled DPoPMode = iota
+	// DPoPAllowed accepts both bearer tokens and DPoP bound tokens. Tokens bound to a DPoP key
+	// must be presented with a DPoP proof.
+	DPoPAllowed
+	// DPoPRequired only accepts DPoP bound tokens presented with a DPoP proof.
+	DPoPRequired
+)
+
+const (
+	dpopProofType              = "dpop+jwt"
+	defaultDPoPProofMaxAge     = time.Minute
+	defaultDPoPReplayCacheSize = 10000
+	headerDPoP                 = "DPoP"
+	headerDPoPNonce            = "DPoP-Nonce"
+	dpopProofIssuedAtTolerance = 5 * time.Second
+)
+
+var (
+	ErrInvalidDPoPProof    = errors.New("dpop proof is invalid")
+	ErrDPoPProofReplayed   = errors.New("dpop proof has been used before")
+	ErrDPoPReplayCacheFull = errors.New("dpop proof cannot be checked for replay as too many proofs are pending")
+	ErrDPoPBindingMismatch = errors.New("access token is not bound to the dpop proof key")
+)
+
+// DPoPMode controls whether Protect middleware accepts DPoP bound access tokens.
+type DPoPMode int
+
+// dpopClaims is the payload of a DPoP proof.
+type dpopClaims struct {
+	ID              string `json:"jti"`
+	Method          string `json:"htm"`
+	URI             string `json:"htu"`
+	IssuedAt        int64  `json:"iat"`
+	AccessTokenHash string `json:"ath,omitempty"`
+	Nonce           string `json:"nonce,omitempty"`
+}
+
+// dpopSigner creates DPoP proofs with the client key, and remembers the latest nonce provided by each server.
+type dpopSigner struct {
+	key    *jwx.Key
+	mu     sync.Mutex
+	nonces map[string]string
+}
+
+func newDPoPSigner(key *jwx.Key) *dpopSigner {
+	return &dpopSigner{key: key, nonces: map[string]string{}}
+}
+
+// proof creates a DPoP proof for the request. When accessToken is not empty, its hash is included in "ath".
+func (d *dpopSigner) proof(method string, u *url.URL, accessToken string) (string, error) {
+	claims := &dpopClaims{
+		ID:       internal.RandomString(16),
+		Method:   method,
+		URI:      dpopTargetURI(u),
+		IssuedAt: time.Now().Unix(),
+	}
+	if len(accessToken) > 0 {
+		claims.AccessTokenHash = accessTokenHash(accessToken)
+	}
+
+	d.mu.Lock()
+	claims.Nonce = d.nonces[u.Scheme+"://"+u.Host]
+	d.mu.Unlock()
+
+	return jwx.EncodeWithJwk(d.key, dpopProofType, claims)
+}
+
+// saveNonce remembers the nonce in the "DPoP-Nonce" header of the response, if any, for subsequent proofs
+// sent to the same server.
+func (d *dpopSigner) saveNonce(resp *http.Response) {
+	if resp == nil || resp.Request == nil {
+		return
+	}
+
+	nonce := resp.Header.Get(headerDPoPNonce)
+	if len(nonce) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	d.nonces[resp.Request.URL.Scheme+"://"+resp.Request.URL.Host] = nonce
+	d.mu.Unlock()
+}
+
+// thumbprint returns the JWK thumbprint of the client key, which is the expected "cnf.jkt" of bound tokens.
+func (d *dpopSigner) thumbprint() (string, error) {
+	return d.key.Thumbprint()
+}
+
+// isNonceChallenge returns true if the resource server responded with a "use_dpop_nonce" error and a new nonce.
+func isNonceChallenge(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized &&
+		len(resp.Header.Get(headerDPoPNonce)) > 0 &&
+		strings.Contains(resp.Header.Get("WWW-Authenticate"), `error="`+oidc.ErrorCodeUseDPoPNonce+`"`)
+}
+
+// dpopVerifier verifies DPoP proofs presented to Protect middleware.
+type dpopVerifier struct {
+	maxAge  time.Duration
+	leeway  time.Duration
+	baseURL string
+	replay  *internal.ReplaySet
+}
+
+func newDPoPVerifier(opt *ProtectOpt) *dpopVerifier {
+	size := opt.DPoPReplayCacheSize
+	if size <= 0 {
+		size = defaultDPoPReplayCacheSize
+	}
+
+	v := &dpopVerifier{
+		maxAge:  opt.DPoPProofMaxAge,
+		leeway:  opt.Leeway,
+		baseURL: strings.TrimSuffix(opt.DPoPBaseURL, "/"),
+		replay:  internal.NewReplaySet(size),
+	}
+	if v.maxAge <= 0 {
+		v.maxAge = defaultDPoPProofMaxAge
+	}
+	if v.leeway < 0 {
+		v.leeway = -v.leeway
+	}
+	return v
+}
+
+// verify verifies the single DPoP proof of the request, presented along with the access token, and returns the
+// JWK thumbprint of the proof key. The "htm", "htu", "iat" and "ath" claims must match the request, and the "jti"
+// claim must not have been seen before. As the "jti" of a proof is remembered until the proof is too old to be
+// accepted, proofs are rejected with ErrDPoPReplayCacheFull when too many are remembered, instead of forgetting
+// earlier ones that could then be replayed.
+func (v *dpopVerifier) verify(r *http.Request, accessToken string) (string, error) {
+	proofs := r.Header.Values(headerDPoP)
+	if len(proofs) != 1 {
+		return "", ErrInvalidDPoPProof
+	}
+
+	header, err := jwx.ReadHeader(proofs[0])
+	if err != nil || header.Type != dpopProofType || jwx.IsNone(header.Alg) || strings.HasPrefix(header.Alg, "HS") {
+		return "", ErrInvalidDPoPProof
+	}
+
+	claims := new(dpopClaims)
+	key, err := jwx.DecodeWithJwk(proofs[0], claims)
+	if err != nil {
+		return "", ErrInvalidDPoPProof
+	}
+
+	if claims.Method != r.Method || len(claims.ID) == 0 {
+		return "", ErrInvalidDPoPProof
+	}
+	if htu, err := url.Parse(claims.URI); err != nil || dpopTargetURI(htu) != v.targetURI(r) {
+		return "", ErrInvalidDPoPProof
+	}
+	if claims.AccessTokenHash != accessTokenHash(accessToken) {
+		return "", ErrInvalidDPoPProof
+	}
+
+	iat := time.Unix(claims.IssuedAt, 0)
+	now := time.Now()
+	if iat.After(now.Add(dpopProofIssuedAtTolerance+v.leeway)) || iat.Before(now.Add(-v.maxAge-v.leeway)) {
+		return "", ErrInvalidDPoPProof
+	}
+
+	switch v.replay.Add(claims.ID, iat.Add(v.maxAge+v.leeway)) {
+	case nil:
+	case internal.ErrReplayed:
+		return "", ErrDPoPProofReplayed
+	default:
+		return "", ErrDPoPReplayCacheFull
+	}
+
+	return key.Thumbprint()
+}
+
+// targetURI reconstructs the "htu" expected for the request.
+func (v *dpopVerifier) targetURI(r *http.Request) string {
+	if len(v.baseURL) > 0 {
+		return v.baseURL + r.URL.Path
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// dpopTargetURI returns the url without query and fragment, as required by "htu".
+func dpopTargetURI(u *url.URL) string {
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+}
+
+// accessTokenHash computes the "ath" claim of the access token.
+func accessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+package tigasdk_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSDK_DPoP(t *testing.T) {
+	var (
+		tigaJwks  = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		clientKey = jwx.GenerateSignatureKey("", jwx.ES256, 0)
+		issuer    string
+	)
+
+	srv := newTigaServer(tigaJwks, map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+
+			var proof struct {
+				Method string `json:"htm"`
+				URI    string `json:"htu"`
+				Nonce  string `json:"nonce"`
+			}
+			key, err := jwx.DecodeWithJwk(r.Header.Get("DPoP"), &proof)
+			if !assert.NoError(t, err) {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			assert.Equal(t, http.MethodPost, proof.Method)
+			assert.Equal(t, issuer+"/oauth/token", proof.URI)
+
+			if proof.Nonce != "server-nonce" {
+				rw.Header().Set("DPoP-Nonce", "server-nonce")
+				rw.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(rw).Encode(&tigasdk.ErrorResponse{Code: oidc.ErrorCodeUseDPoPNonce})
+				return
+			}
+
+			jkt, _ := key.Thumbprint()
+			accessToken, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, &tigasdk.AccessTokenClaims{
+				Claims: jwt.Claims{
+					Issuer: issuer,
+					Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+				Cnf: &tigasdk.Confirmation{Jkt: jkt},
+			})
+			assert.NoError(t, err)
+
+			expiresIn := int64(60)
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{
+				AccessToken: accessToken,
+				TokenType:   tigasdk.DPoPTokenType,
+				ExpiresIn:   &expiresIn,
+			})
+		},
+	})
+	defer srv.Close()
+	issuer = srv.URL
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+		tigasdk.WithDPoP(clientKey),
+	)
+	assert.NoError(t, err)
+
+	tr, err := sdk.TokenByClientCredentials(context.Background(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, tigasdk.DPoPTokenType, tr.TokenType)
+
+	var lastProof string
+	resource := httptest.NewServer(sdk.Protect(&tigasdk.ProtectOpt{DPoP: tigasdk.DPoPRequired})(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			lastProof = r.Header.Get("DPoP")
+			tok, err := tigasdk.GetAccessToken(r.Context())
+			assert.NoError(t, err)
+			assert.Equal(t, tigasdk.DPoPTokenType, tok.Type)
+			rw.WriteHeader(http.StatusOK)
+		}),
+	))
+	defer resource.Close()
+
+	client := &http.Client{Transport: sdk.TokenSource(tr, nil).RoundTripper(nil)}
+	resp, err := client.Get(resource.URL + "/orders?page=1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	send := func(scheme string, proof string) int {
+		r, _ := http.NewRequest(http.MethodGet, resource.URL+"/orders", nil)
+		r.Header.Set("Authorization", scheme+" "+tr.AccessToken)
+		if len(proof) > 0 {
+			r.Header.Set("DPoP", proof)
+		}
+		resp, err := http.DefaultClient.Do(r)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send(tigasdk.DPoPTokenType, lastProof), "replayed proof")
+	assert.Equal(t, http.StatusUnauthorized, send(tigasdk.AccessTokenType, ""), "bearer presentation")
+
+	otherKey := jwx.GenerateSignatureKey("", jwx.ES256, 0)
+	ath := sha256.Sum256([]byte(tr.AccessToken))
+	proof, err := jwx.EncodeWithJwk(otherKey, "dpop+jwt", map[string]interface{}{
+		"jti": "other",
+		"htm": http.MethodGet,
+		"htu": resource.URL + "/orders",
+		"iat": time.Now().Unix(),
+		"ath": base64.RawURLEncoding.EncodeToString(ath[:]),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, send(tigasdk.DPoPTokenType, proof), "proof by another key")
+
+	t.Run("replay after the cache is full", func(t *testing.T) {
+		var proofs []string
+		resource := httptest.NewServer(sdk.Protect(&tigasdk.ProtectOpt{DPoP: tigasdk.DPoPRequired, DPoPReplayCacheSize: 2})(
+			http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				proofs = append(proofs, r.Header.Get("DPoP"))
+				rw.WriteHeader(http.StatusOK)
+			}),
+		))
+		defer resource.Close()
+
+		get := func() int {
+			resp, err := client.Get(resource.URL + "/orders")
+			if !assert.NoError(t, err) {
+				return 0
+			}
+			return resp.StatusCode
+		}
+
+		assert.Equal(t, http.StatusOK, get())
+		assert.Equal(t, http.StatusOK, get())
+		assert.Equal(t, http.StatusUnauthorized, get(), "new proof while full")
+
+		for _, p := range proofs {
+			r, _ := http.NewRequest(http.MethodGet, resource.URL+"/orders", nil)
+			r.Header.Set("Authorization", tigasdk.DPoPTokenType+" "+tr.AccessToken)
+			r.Header.Set("DPoP", p)
+			resp, err := http.DefaultClient.Do(r)
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "replayed proof")
+			}
+		}
+	})
+}
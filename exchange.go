@@ -0,0 +1,86 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"strings"
+)
+
+var (
+	ErrMissingSubjectToken = errors.New("subject_token is required")
+)
+
+// ExchangeRequest is the parameters to exchange a token for another at the token endpoint.
+type ExchangeRequest struct {
+	// SubjectToken is the token that represents the identity of the party on whose behalf the
+	// request is made, typically the access token of the End-User. Required.
+	SubjectToken string
+
+	// SubjectTokenType is the type of SubjectToken. When empty, oidc.TokenTypeAccessToken is used.
+	SubjectTokenType string
+
+	// ActorToken is the optional token that represents the identity of the acting party. When set, the
+	// issued token is a delegation token, carrying the acting party in the "act" claim.
+	ActorToken string
+
+	// ActorTokenType is the type of ActorToken. When empty and ActorToken is set, oidc.TokenTypeAccessToken is used.
+	ActorTokenType string
+
+	// RequestedTokenType is the optional type of the requested token.
+	RequestedTokenType string
+
+	// Audience is the optional logical name of the target service where the issued token is intended to be used.
+	Audience string
+
+	// Resource is the optional URI of the target service where the issued token is intended to be used.
+	Resource string
+
+	// Scopes is the optional list of requested scopes, usually narrower than those of SubjectToken.
+	Scopes []string
+}
+
+// ExchangeToken exchanges the subject token, and optionally the actor token, for a new token, typically to call
+// downstream services on behalf of the End-User with narrowed audience and scopes. The type of the issued token
+// is returned in TokenResponse.IssuedTokenType.
+func (s *SDK) ExchangeToken(ctx context.Context, req *ExchangeRequest) (*TokenResponse, error) {
+	if len(req.SubjectToken) == 0 {
+		return nil, ErrMissingSubjectToken
+	}
+
+	var (
+		subjectTokenType = internal.Coalesce(req.SubjectTokenType, oidc.TokenTypeAccessToken)
+		actorTokenType   string
+	)
+	if len(req.ActorToken) > 0 {
+		actorTokenType = internal.Coalesce(req.ActorTokenType, oidc.TokenTypeAccessToken)
+	}
+
+	for _, tokenType := range []string{subjectTokenType, actorTokenType, req.RequestedTokenType} {
+		if len(tokenType) == 0 {
+			continue
+		}
+		if err := oidc.ValidTokenType(tokenType); err != nil {
+			return nil, err
+		}
+	}
+
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":            s.clientId,
+		"grant_type":           oidc.GrantTypeTokenExchange,
+		"subject_token":        req.SubjectToken,
+		"subject_token_type":   subjectTokenType,
+		"actor_token":          req.ActorToken,
+		"actor_token_type":     actorTokenType,
+		"requested_token_type": req.RequestedTokenType,
+		"audience":             req.Audience,
+		"resource":             req.Resource,
+		"scope":                strings.Join(req.Scopes, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeTokenRequest(ctx, options)
+}
@@ -0,0 +1,83 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSDK_ExchangeToken(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, oidc.GrantTypeTokenExchange, r.PostFormValue("grant_type"))
+			assert.Equal(t, "user-token", r.PostFormValue("subject_token"))
+			assert.Equal(t, oidc.TokenTypeAccessToken, r.PostFormValue("subject_token_type"))
+			assert.Equal(t, "orders", r.PostFormValue("audience"))
+			assert.Equal(t, "orders:read", r.PostFormValue("scope"))
+			assert.Empty(t, r.PostFormValue("actor_token_type"))
+
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{
+				AccessToken:     "delegated-token",
+				TokenType:       "Bearer",
+				IssuedTokenType: oidc.TokenTypeAccessToken,
+			})
+		},
+		"/oauth/introspect": func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.IntrospectionResponse{
+				Active:   true,
+				ClientId: "gateway",
+				Subject:  "user",
+				Expiry:   time.Now().Add(time.Minute).Unix(),
+				Act: &tigasdk.Actor{
+					Subject: "gateway",
+					Actor:   &tigasdk.Actor{Subject: "edge"},
+				},
+			})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("gateway", "secret"),
+	)
+	assert.NoError(t, err)
+
+	tr, err := sdk.ExchangeToken(context.Background(), &tigasdk.ExchangeRequest{
+		SubjectToken: "user-token",
+		Audience:     "orders",
+		Scopes:       []string{"orders:read"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "delegated-token", tr.AccessToken)
+	assert.Equal(t, oidc.TokenTypeAccessToken, tr.IssuedTokenType)
+
+	handler := sdk.Protect(&tigasdk.ProtectOpt{
+		Introspection: tigasdk.IntrospectAlways,
+	})(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		tok, err := tigasdk.GetAccessToken(r.Context())
+		if assert.NoError(t, err) && assert.NotNil(t, tok.Actor) {
+			assert.Equal(t, "gateway", tok.Actor.Subject)
+			assert.Equal(t, "edge", tok.Actor.Actor.Subject)
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+tr.AccessToken)
+	handler.ServeHTTP(rw, r)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	_, err = sdk.ExchangeToken(context.Background(), &tigasdk.ExchangeRequest{})
+	assert.Equal(t, tigasdk.ErrMissingSubjectToken, err)
+}
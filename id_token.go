@@ -0,0 +1,204 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"time"
+)
+
+var (
+	ErrInvalidIdToken       = errors.New("id token is invalid")
+	ErrIdTokenNotEncrypted  = errors.New("id token is expected to be encrypted")
+	ErrInvalidIdTokenEncAlg = errors.New("id token encryption algorithms do not match the registered ones")
+	ErrInvalidIdTokenSigAlg = errors.New("id token signing algorithm is not supported")
+	ErrInvalidNonce         = errors.New("nonce claim is invalid")
+	ErrInvalidAzp           = errors.New("azp claim is invalid")
+	ErrAuthTimeExpired      = errors.New("auth_time claim is invalid because authentication is older than max_age")
+	ErrInvalidAtHash        = errors.New("at_hash claim does not match access token")
+	ErrInvalidCHash         = errors.New("c_hash claim does not match authorization code")
+)
+
+// IdTokenOpt is the options for VerifyIdToken.
+type IdTokenOpt struct {
+	// Nonce is the expected "nonce" claim, which was sent in the authorize request.
+	// When empty, "nonce" validation is not performed.
+	Nonce string
+
+	// MaxAge is the max_age in seconds sent in the authorize request. When set,
+	// "auth_time" must be present and not older than max_age.
+	MaxAge *int64
+
+	// AccessToken is the access token issued alongside the id token. When set and
+	// the "at_hash" claim is present, the claim must match the access token.
+	AccessToken string
+
+	// Code is the authorization code issued alongside the id token. When set and
+	// the "c_hash" claim is present, the claim must match the code.
+	Code string
+
+	// Leeway is the time skew tolerance
+	Leeway time.Duration
+}
+
+// VerifyIdToken decrypts (when WithIdTokenEncryption is set) and verifies the id token against the Tiga jwks, and
+// validates its claims. Encrypted id tokens must use the algorithms set by WithIdTokenEncryption. The signing
+// algorithm must be one of those advertised in IdTokenSigningAlgValuesSupported.
+// The claims "iss", "aud", "azp", "exp", "iat" and "nbf" are always validated; "nonce", "auth_time", "at_hash" and
+// "c_hash" are validated according to IdTokenOpt.
+func (s *SDK) VerifyIdToken(ctx context.Context, idToken string, opt *IdTokenOpt) (*IdTokenClaims, error) {
+	if opt == nil {
+		opt = &IdTokenOpt{}
+	}
+
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := jwx.ReadHeader(idToken)
+	if err != nil {
+		return nil, ErrInvalidIdToken
+	}
+
+	encrypted := !jwx.IsNone(s.idTokenAlgs.Encrypt) && !jwx.IsNone(s.idTokenAlgs.Encode)
+	switch {
+	case encrypted && !header.IsEncrypted():
+		return nil, ErrIdTokenNotEncrypted
+	case header.IsEncrypted():
+		if header.Alg != s.idTokenAlgs.Encrypt || header.Enc != s.idTokenAlgs.Encode {
+			return nil, ErrInvalidIdTokenEncAlg
+		}
+
+		decrypted, err := jwx.Decrypt(idToken, s.clientJwks)
+		if err != nil {
+			return nil, err
+		}
+		idToken = string(decrypted)
+
+		if header, err = jwx.ReadHeader(idToken); err != nil {
+			return nil, ErrInvalidIdToken
+		}
+	}
+
+	if jwx.IsNone(header.Alg) {
+		return nil, ErrInvalidIdTokenSigAlg
+	}
+	if supported := discovery.IdTokenSigningAlgValuesSupported; len(supported) > 0 && !internal.NewSet(supported...).Contains(header.Alg) {
+		return nil, ErrInvalidIdTokenSigAlg
+	}
+
+	claims := new(IdTokenClaims)
+	if err := s.decode(ctx, idToken, nil, jwx.Algs{Sig: header.Alg}, claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Expiry == nil {
+		return nil, jwx.ErrExpExpired
+	}
+
+	rules := []jwx.Expect{
+		jwx.ExpectIss(discovery.Issuer),
+		jwx.ExpectAud(s.clientId),
+		jwx.ExpectTime(opt.Leeway),
+		expectAzp(s.clientId),
+	}
+	if len(opt.Nonce) > 0 {
+		rules = append(rules, expectNonce(opt.Nonce))
+	}
+	if opt.MaxAge != nil {
+		rules = append(rules, expectAuthTime(*opt.MaxAge, opt.Leeway))
+	}
+	if len(opt.AccessToken) > 0 {
+		rules = append(rules, expectHalfHash(oidc.ClaimAtHash, header.Alg, opt.AccessToken, ErrInvalidAtHash))
+	}
+	if len(opt.Code) > 0 {
+		rules = append(rules, expectHalfHash(oidc.ClaimCHash, header.Alg, opt.Code, ErrInvalidCHash))
+	}
+
+	if err := jwx.ValidateClaims(claims, rules...); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// expectAzp expects the "azp" claim, if present, to be the client id. When the id token contains multiple
+// audiences, "azp" must be present.
+func expectAzp(clientId string) jwx.Expect {
+	return func(c jwx.Claims) error {
+		var azp string
+		if v, ok := c.Get(oidc.ClaimAzp); ok {
+			azp, _ = v.(string)
+		}
+
+		if v, ok := c.Get(jwx.ClaimAud); ok {
+			if aud, ok := v.([]string); ok && len(aud) > 1 && len(azp) == 0 {
+				return ErrInvalidAzp
+			}
+		}
+
+		if len(azp) > 0 && azp != clientId {
+			return ErrInvalidAzp
+		}
+
+		return nil
+	}
+}
+
+// expectNonce expects the "nonce" claim to be present and equal to nonce.
+func expectNonce(nonce string) jwx.Expect {
+	return func(c jwx.Claims) error {
+		if v, ok := c.Get(oidc.ClaimNonce); ok {
+			if n, ok := v.(string); ok && n == nonce {
+				return nil
+			}
+		}
+		return ErrInvalidNonce
+	}
+}
+
+// expectAuthTime expects the "auth_time" claim to be present and not older than maxAge seconds.
+func expectAuthTime(maxAge int64, leeway time.Duration) jwx.Expect {
+	if leeway < 0 {
+		leeway = -leeway
+	}
+	return func(c jwx.Claims) error {
+		if v, ok := c.Get(oidc.ClaimAuthTime); ok {
+			if authTime, ok := v.(time.Time); ok && !authTime.IsZero() {
+				if time.Now().After(authTime.Add(time.Duration(maxAge)*time.Second + leeway)) {
+					return ErrAuthTimeExpired
+				}
+				return nil
+			}
+		}
+		return ErrAuthTimeExpired
+	}
+}
+
+// expectHalfHash expects the claim, if present, to be the half hash of value computed with alg.
+func expectHalfHash(claim string, alg string, value string, invalid error) jwx.Expect {
+	return func(c jwx.Claims) error {
+		v, ok := c.Get(claim)
+		if !ok {
+			return nil
+		}
+
+		expected, ok := v.(string)
+		if !ok {
+			return invalid
+		}
+		if len(expected) == 0 {
+			return nil
+		}
+
+		actual, err := jwx.HalfHash(alg, value)
+		if err != nil || actual != expected {
+			return invalid
+		}
+
+		return nil
+	}
+}
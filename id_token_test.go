@@ -0,0 +1,134 @@
+package tigasdk_test
+
+import (
+	"context"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"testing"
+	"time"
+)
+
+func TestSDK_VerifyIdToken(t *testing.T) {
+	var (
+		tigaJwks = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		srv      = newTigaServer(tigaJwks, nil)
+	)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	atHash, err := jwx.HalfHash(jwx.ES256, "access-token")
+	assert.NoError(t, err)
+
+	issue := func(modify func(c map[string]interface{})) string {
+		c := map[string]interface{}{
+			"iss":       srv.URL,
+			"sub":       "user",
+			"aud":       "client",
+			"exp":       time.Now().Add(time.Minute).Unix(),
+			"iat":       time.Now().Unix(),
+			"auth_time": time.Now().Add(-time.Minute).Unix(),
+			"nonce":     "n-0S6_WzA2Mj",
+			"at_hash":   atHash,
+			"email":     "user@tiga.test",
+		}
+		if modify != nil {
+			modify(c)
+		}
+		token, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, c)
+		assert.NoError(t, err)
+		return token
+	}
+
+	maxAge := int64(300)
+	opt := &tigasdk.IdTokenOpt{Nonce: "n-0S6_WzA2Mj", MaxAge: &maxAge, AccessToken: "access-token"}
+
+	claims, err := sdk.VerifyIdToken(context.Background(), issue(nil), opt)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user", claims.Subject)
+		assert.Equal(t, "user@tiga.test", claims.Raw["email"])
+	}
+
+	for _, c := range []struct {
+		name   string
+		modify func(c map[string]interface{})
+		expect error
+	}{
+		{name: "wrong audience", modify: func(c map[string]interface{}) { c["aud"] = "other" }, expect: jwx.ErrInvalidAud},
+		{name: "wrong nonce", modify: func(c map[string]interface{}) { c["nonce"] = "replayed" }, expect: tigasdk.ErrInvalidNonce},
+		{name: "wrong at_hash", modify: func(c map[string]interface{}) { c["at_hash"] = "AAAA" }, expect: tigasdk.ErrInvalidAtHash},
+		{name: "missing azp", modify: func(c map[string]interface{}) { c["aud"] = []string{"client", "other"} }, expect: tigasdk.ErrInvalidAzp},
+		{name: "stale auth_time", modify: func(c map[string]interface{}) { c["auth_time"] = time.Now().Add(-time.Hour).Unix() }, expect: tigasdk.ErrAuthTimeExpired},
+		{name: "expired", modify: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, expect: jwx.ErrExpExpired},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := sdk.VerifyIdToken(context.Background(), issue(c.modify), opt)
+			assert.Equal(t, c.expect, err)
+		})
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		token, _ := jwx.EncodeToString(nil, nil, jwt.Claims{Issuer: srv.URL})
+		_, err := sdk.VerifyIdToken(context.Background(), token, opt)
+		assert.Error(t, err)
+	})
+}
+
+func TestSDK_VerifyIdToken_Encrypted(t *testing.T) {
+	var (
+		tigaJwks   = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		clientJwks = jwx.NewKeySet(
+			jwx.GenerateEncryptionKey("rsa", jwx.RSA_OAEP_256, 2048),
+			jwx.GenerateEncryptionKey("ec", jwx.ECDH_ES, 0),
+		)
+		srv = newTigaServer(tigaJwks, nil)
+	)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("client", "secret"),
+		tigasdk.WithClientJwks(clientJwks),
+		tigasdk.WithIdTokenEncryption(jwx.RSA_OAEP_256, jwx.A256GCM),
+	)
+	assert.NoError(t, err)
+
+	claims := map[string]interface{}{
+		"iss": srv.URL,
+		"sub": "user",
+		"aud": "client",
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	for _, c := range []struct {
+		name   string
+		alg    string
+		enc    string
+		expect error
+	}{
+		{name: "registered algorithms", alg: jwx.RSA_OAEP_256, enc: jwx.A256GCM},
+		{name: "other content encryption", alg: jwx.RSA_OAEP_256, enc: jwx.A128CBC_HS256, expect: tigasdk.ErrInvalidIdTokenEncAlg},
+		{name: "other key encryption", alg: jwx.ECDH_ES, enc: jwx.A256GCM, expect: tigasdk.ErrInvalidIdTokenEncAlg},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			token, err := jwx.EncodeToString(
+				jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks),
+				jwx.EncryptionKeyByAlg(c.alg, c.enc, clientJwks.ToPublic()),
+				claims,
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = sdk.VerifyIdToken(context.Background(), token, nil)
+			assert.Equal(t, c.expect, err)
+		})
+	}
+}
@@ -19,34 +19,58 @@ var (
 )
 
 func (s *SDK) LoginState(ctx context.Context, xid string) (*InteractionState, error) {
-	return s.getInteractionState(ctx, s.discovery.LoginEndpoint, []string{oidc.ScopeTigaLogin}, xid)
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getInteractionState(ctx, discovery.LoginEndpoint, []string{oidc.ScopeTigaLogin}, xid)
 }
 
 func (s *SDK) SelectAccountState(ctx context.Context, xid string) (*InteractionState, error) {
-	return s.getInteractionState(ctx, s.discovery.SelectAccountEndpoint, []string{oidc.ScopeTigaSelectAccount}, xid)
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getInteractionState(ctx, discovery.SelectAccountEndpoint, []string{oidc.ScopeTigaSelectAccount}, xid)
 }
 
 func (s *SDK) ConsentState(ctx context.Context, xid string) (*InteractionState, error) {
-	return s.getInteractionState(ctx, s.discovery.ConsentEndpoint, []string{oidc.ScopeTigaConsent}, xid)
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getInteractionState(ctx, discovery.ConsentEndpoint, []string{oidc.ScopeTigaConsent}, xid)
 }
 
 func (s *SDK) LoginCallback(ctx context.Context, xid string, callback *LoginCallback) (bool, error) {
-	if limit := s.discovery.InteractionContextDataKBLimit; limit > 0 && int64(len(callback.Context))/1024 > limit {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return false, err
+	}
+	if limit := discovery.InteractionContextDataKBLimit; limit > 0 && int64(len(callback.Context))/1024 > limit {
 		return false, ErrContextTooLarge
 	}
-	return s.interactionCallback(ctx, s.discovery.LoginEndpoint, []string{oidc.ScopeTigaLogin}, callback, xid)
+	return s.interactionCallback(ctx, discovery.LoginEndpoint, []string{oidc.ScopeTigaLogin}, callback, xid)
 }
 
 func (s *SDK) SelectAccountCallback(ctx context.Context, xid string, callback *SelectAccountCallback) (bool, error) {
-	return s.interactionCallback(ctx, s.discovery.SelectAccountEndpoint, []string{oidc.ScopeTigaSelectAccount}, callback, xid)
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return false, err
+	}
+	return s.interactionCallback(ctx, discovery.SelectAccountEndpoint, []string{oidc.ScopeTigaSelectAccount}, callback, xid)
 }
 
 func (s *SDK) ConsentCallback(ctx context.Context, xid string, callback *ConsentCallback) (bool, error) {
-	return s.interactionCallback(ctx, s.discovery.ConsentEndpoint, []string{oidc.ScopeTigaConsent}, callback, xid)
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return false, err
+	}
+	return s.interactionCallback(ctx, discovery.ConsentEndpoint, []string{oidc.ScopeTigaConsent}, callback, xid)
 }
 
 func (s *SDK) interactionCallback(ctx context.Context, endpoint string, scopes []string, callback interface{}, xid string) (bool, error) {
-	tr, err := s.TokenByClientCredentials(ctx, scopes)
+	tr, err := s.ClientCredentialsToken(ctx, scopes)
 	if err != nil {
 		return false, err
 	}
@@ -78,7 +102,7 @@ func (s *SDK) interactionCallback(ctx context.Context, endpoint string, scopes [
 }
 
 func (s *SDK) getInteractionState(ctx context.Context, endpoint string, scopes []string, xid string) (*InteractionState, error) {
-	tr, err := s.TokenByClientCredentials(ctx, scopes)
+	tr, err := s.ClientCredentialsToken(ctx, scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +139,13 @@ func (s *SDK) getInteractionState(ctx context.Context, endpoint string, scopes [
 }
 
 func (s *SDK) ResumeAuthorize(rw http.ResponseWriter, r *http.Request, xid string) {
-	u, _ := url.Parse(s.discovery.AuthorizeResumeEndpoint)
+	discovery, err := s.getDiscovery(r.Context())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	u, _ := url.Parse(discovery.AuthorizeResumeEndpoint)
 	u.RawQuery = url.Values{"xid": []string{xid}}.Encode()
 	http.Redirect(rw, r, u.String(), http.StatusFound)
 }
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// NewCache creates a Cache which holds at most capacity entries.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Cache is a bounded key value cache with per entry expiry. When the capacity is reached, the least
+// recently used entry is evicted. Cache is safe for concurrent use.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type cacheEntry struct {
+	key    string
+	value  interface{}
+	expiry time.Time
+}
+
+// Get returns the value of the key, if it exists and has not expired.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key)
+}
+
+// Put sets the value of the key until expiry.
+func (c *Cache) Put(key string, value interface{}, expiry time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(key, value, expiry)
+}
+
+// Add sets the value of the key until expiry, only if the key does not exist or has expired. It returns
+// false if the key already exists. It is useful to detect replays.
+func (c *Cache) Add(key string, value interface{}, expiry time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.get(key); ok {
+		return false
+	}
+	c.put(key, value, expiry)
+	return true
+}
+
+// Take returns the value of the key, if it exists and has not expired, and removes it from the cache.
+func (c *Cache) Take(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.get(key)
+	if ok {
+		c.order.Remove(c.entries[key])
+		delete(c.entries, key)
+	}
+	return v, ok
+}
+
+func (c *Cache) get(key string) (interface{}, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiry) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *Cache) put(key string, value interface{}, expiry time.Time) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &cacheEntry{key: key, value: value, expiry: expiry}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expiry: expiry})
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// Detach returns a context which carries the values of ctx, but is never canceled and has no deadline. It is
+// useful to run work shared by several callers, which must not be canceled by any one of them.
+func Detach(ctx context.Context) context.Context {
+	return detached{parent: ctx}
+}
+
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detached) Done() <-chan struct{} { return nil }
+
+func (detached) Err() error { return nil }
+
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
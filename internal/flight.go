@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"sync"
+)
+
+// Flight de-duplicates concurrent function calls with the same key, so that only one call is in flight
+// at a time while the other callers wait for and share its result.
+type Flight struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// Do executes fn for the given key, unless a call with the same key is already in flight, in which case
+// it waits for that call to complete and returns its results.
+func (f *Flight) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = map[string]*flightCall{}
+	}
+	if c, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+
+	c := new(flightCall)
+	c.wg.Add(1)
+	f.calls[key] = c
+	f.mu.Unlock()
+
+	c.val, c.err = fn()
+	c.wg.Done()
+
+	f.mu.Lock()
+	delete(f.calls, key)
+	f.mu.Unlock()
+
+	return c.val, c.err
+}
+
+// DoContext is like Do, but returns ctx.Err() as soon as ctx is done, without waiting for the call to complete.
+// The call keeps running for the other callers, hence fn must not depend on ctx.
+func (f *Flight) DoContext(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	type result struct {
+		val interface{}
+		err error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		v, err := f.Do(key, fn)
+		ch <- result{val: v, err: err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.val, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
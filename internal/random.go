@@ -0,0 +1,16 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// RandomString returns a base64 url encoded string of n cryptographically random bytes. It panics if the
+// system random source fails, which is not expected to happen.
+func RandomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrReplayed      = errors.New("key has been seen before")
+	ErrReplaySetFull = errors.New("replay set is full")
+)
+
+// NewReplaySet creates a ReplaySet which remembers at most capacity keys.
+func NewReplaySet(capacity int) *ReplaySet {
+	return &ReplaySet{
+		capacity: capacity,
+		entries:  map[string]time.Time{},
+	}
+}
+
+// ReplaySet remembers keys until their expiry to detect replays. Unlike Cache, keys are never evicted before they
+// expire, as that would allow replays; when the capacity is reached, new keys are rejected until some keys expire.
+// ReplaySet is safe for concurrent use.
+type ReplaySet struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]time.Time
+	// earliest is the earliest expiry among the entries, before which purging cannot free up any space
+	earliest time.Time
+}
+
+// Add remembers the key until expiry. It returns ErrReplayed if the key has been added before and has not
+// expired, or ErrReplaySetFull if the capacity is reached.
+func (s *ReplaySet) Add(key string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if e, ok := s.entries[key]; ok && !now.After(e) {
+		return ErrReplayed
+	}
+
+	if s.capacity > 0 && len(s.entries) >= s.capacity {
+		if now.Before(s.earliest) {
+			return ErrReplaySetFull
+		}
+		s.purge(now)
+		if len(s.entries) >= s.capacity {
+			return ErrReplaySetFull
+		}
+	}
+
+	s.entries[key] = expiry
+	if len(s.entries) == 1 || expiry.Before(s.earliest) {
+		s.earliest = expiry
+	}
+
+	return nil
+}
+
+func (s *ReplaySet) purge(now time.Time) {
+	s.earliest = time.Time{}
+	for k, e := range s.entries {
+		if now.After(e) {
+			delete(s.entries, k)
+		} else if s.earliest.IsZero() || e.Before(s.earliest) {
+			s.earliest = e
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package tigasdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/imulab/coldcall"
+	"github.com/imulab/coldcall/body"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"strings"
+	"time"
+)
+
+// Modes of introspection in Protect middleware.
+const (
+	// IntrospectNever never introspects the access token. Only JWT access tokens are accepted.
+	IntrospectNever IntrospectionMode = iota
+	// IntrospectFallback introspects the access token only when it cannot be verified locally as JWT,
+	// for instance, when it is an opaque token.
+	IntrospectFallback
+	// IntrospectAlways always introspects the access token, which gives real-time revocation status.
+	IntrospectAlways
+)
+
+const (
+	defaultIntrospectionCacheSize = 1000
+	defaultIntrospectionCacheTTL  = 30 * time.Second
+	// results without "exp" may belong to tokens that never expire, hence are only cached briefly
+	introspectionCacheTTLWithoutExpiry = time.Second
+)
+
+var (
+	ErrIntrospectionNotSupported = errors.New("introspection endpoint is not available")
+)
+
+// IntrospectionMode controls when Protect middleware introspects the access token.
+type IntrospectionMode int
+
+// IntrospectionResponse is the response object at introspection endpoint.
+type IntrospectionResponse struct {
+	Active    bool          `json:"active"`
+	Scope     string        `json:"scope,omitempty"`
+	ClientId  string        `json:"client_id,omitempty"`
+	Username  string        `json:"username,omitempty"`
+	TokenType string        `json:"token_type,omitempty"`
+	Expiry    int64         `json:"exp,omitempty"`
+	IssuedAt  int64         `json:"iat,omitempty"`
+	NotBefore int64         `json:"nbf,omitempty"`
+	Subject   string        `json:"sub,omitempty"`
+	Audience  jwt.Audience  `json:"aud,omitempty"`
+	Issuer    string        `json:"iss,omitempty"`
+	JwtId     string        `json:"jti,omitempty"`
+	Act       *Actor        `json:"act,omitempty"`
+	Cnf       *Confirmation `json:"cnf,omitempty"`
+
+	// Raw contains all members of the response, including those
+	// not mapped to the fields above.
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (r *IntrospectionResponse) UnmarshalJSON(bytes []byte) error {
+	type plain IntrospectionResponse
+	if err := json.Unmarshal(bytes, (*plain)(r)); err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, &r.Raw)
+}
+
+// Introspect requests the introspection endpoint to determine the state of the token, authenticating the client
+// with the configured token endpoint authentication method. The hint is optional, and can be one of
+// oidc.TokenTypeHintAccessToken and oidc.TokenTypeHintRefreshToken. Inactive tokens are not treated as error, check
+// IntrospectionResponse.Active instead.
+func (s *SDK) Introspect(ctx context.Context, token string, hint string) (*IntrospectionResponse, error) {
+	if len(hint) > 0 {
+		if err := oidc.ValidTokenTypeHint(hint); err != nil {
+			return nil, err
+		}
+	}
+
+	discovery, err := s.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(discovery.IntrospectionEndpoint) == 0 {
+		return nil, ErrIntrospectionNotSupported
+	}
+
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":       s.clientId,
+		"token":           token,
+		"token_type_hint": hint,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var successConstructor coldcall.Constructor = func() interface{} { return new(IntrospectionResponse) }
+
+	result, err := s.postForm(ctx, discovery.IntrospectionEndpoint, options, body.JSONUnmarshal(successConstructor))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*IntrospectionResponse), nil
+}
+
+// isAccessToken returns false if the "token_type" of the response is present but not of an access token, for
+// instance, when it is a refresh token. Both the token type of the access token response (i.e. Bearer or DPoP),
+// and the token type hint value of access tokens are recognized.
+func (r *IntrospectionResponse) isAccessToken() bool {
+	switch {
+	case len(r.TokenType) == 0,
+		strings.EqualFold(r.TokenType, AccessTokenType),
+		strings.EqualFold(r.TokenType, DPoPTokenType),
+		r.TokenType == oidc.TokenTypeHintAccessToken:
+		return true
+	default:
+		return false
+	}
+}
+
+// toClaims converts the introspection response to AccessTokenClaims, so that it can be validated in the same way
+// as a JWT access token. When the response does not include "iss", the issuer is assumed.
+func (r *IntrospectionResponse) toClaims(issuer string) *AccessTokenClaims {
+	numericDate := func(v int64) *jwt.NumericDate {
+		if v == 0 {
+			return nil
+		}
+		d := jwt.NumericDate(v)
+		return &d
+	}
+
+	return &AccessTokenClaims{
+		Claims: jwt.Claims{
+			Issuer:    internal.Coalesce(r.Issuer, issuer),
+			Subject:   r.Subject,
+			Audience:  r.Audience,
+			Expiry:    numericDate(r.Expiry),
+			NotBefore: numericDate(r.NotBefore),
+			IssuedAt:  numericDate(r.IssuedAt),
+			ID:        r.JwtId,
+		},
+		Client: r.ClientId,
+		Scope:  r.Scope,
+		Act:    r.Act,
+		Cnf:    r.Cnf,
+	}
+}
+
+// introspectionCache caches introspection results by the hash of the token.
+type introspectionCache struct {
+	cache *internal.Cache
+	ttl   time.Duration
+}
+
+func newIntrospectionCache(size int, ttl time.Duration) *introspectionCache {
+	if ttl < 0 {
+		return nil
+	}
+	if ttl == 0 {
+		ttl = defaultIntrospectionCacheTTL
+	}
+	if size <= 0 {
+		size = defaultIntrospectionCacheSize
+	}
+	return &introspectionCache{cache: internal.NewCache(size), ttl: ttl}
+}
+
+// introspect returns the cached introspection result of the token, or introspects the token with the
+// function and caches the result for the ttl, but no later than the expiry of the token. Results without
+// expiry are cached for introspectionCacheTTLWithoutExpiry at most.
+func (c *introspectionCache) introspect(
+	ctx context.Context,
+	token string,
+	fn func(ctx context.Context, token string) (*IntrospectionResponse, error),
+) (*IntrospectionResponse, error) {
+	if c == nil {
+		return fn(ctx, token)
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+
+	if v, ok := c.cache.Get(key); ok {
+		return v.(*IntrospectionResponse), nil
+	}
+
+	resp, err := fn(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := time.Now().Add(c.ttl)
+	switch {
+	case resp.Expiry > 0:
+		if time.Unix(resp.Expiry, 0).Before(expiry) {
+			expiry = time.Unix(resp.Expiry, 0)
+		}
+	case c.ttl > introspectionCacheTTLWithoutExpiry:
+		expiry = time.Now().Add(introspectionCacheTTLWithoutExpiry)
+	}
+	c.cache.Put(key, resp, expiry)
+
+	return resp, nil
+}
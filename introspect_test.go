@@ -0,0 +1,100 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSDK_ProtectWithIntrospection(t *testing.T) {
+	var introspected, introspectedWithoutExpiry int32
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/introspect": func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&introspected, 1)
+			id, _, _ := r.BasicAuth()
+			assert.Equal(t, "resource-server", id)
+			assert.Equal(t, "access_token", r.PostFormValue("token_type_hint"))
+
+			resp := &tigasdk.IntrospectionResponse{Active: false}
+			switch r.PostFormValue("token") {
+			case "opaque-token":
+				resp = &tigasdk.IntrospectionResponse{
+					Active:    true,
+					Scope:     "foo bar",
+					ClientId:  "client",
+					Subject:   "user",
+					TokenType: "Bearer",
+					Expiry:    time.Now().Add(time.Minute).Unix(),
+				}
+			case "refresh-token":
+				resp = &tigasdk.IntrospectionResponse{
+					Active:    true,
+					Scope:     "foo bar",
+					ClientId:  "client",
+					Subject:   "user",
+					TokenType: "refresh_token",
+					Expiry:    time.Now().Add(time.Hour).Unix(),
+				}
+			case "token-without-expiry":
+				atomic.AddInt32(&introspectedWithoutExpiry, 1)
+				resp = &tigasdk.IntrospectionResponse{
+					Active:   true,
+					Scope:    "foo bar",
+					ClientId: "client",
+					Subject:  "user",
+				}
+			}
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(resp)
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("resource-server", "secret"),
+	)
+	assert.NoError(t, err)
+
+	handler := sdk.Protect(&tigasdk.ProtectOpt{
+		Scopes:                []string{"foo"},
+		Introspection:         tigasdk.IntrospectFallback,
+		IntrospectionCacheTTL: time.Hour,
+	})(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		tok, err := tigasdk.GetAccessToken(r.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "client", tok.ClientId)
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(token string) int {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("opaque-token"))
+	assert.Equal(t, http.StatusOK, serve("opaque-token"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&introspected))
+
+	assert.Equal(t, http.StatusUnauthorized, serve("revoked-token"))
+	assert.Equal(t, http.StatusUnauthorized, serve("refresh-token"))
+
+	// results without expiry are cached briefly regardless of IntrospectionCacheTTL
+	assert.Equal(t, http.StatusOK, serve("token-without-expiry"))
+	assert.Equal(t, http.StatusOK, serve("token-without-expiry"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&introspectedWithoutExpiry))
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve("token-without-expiry"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&introspectedWithoutExpiry))
+}
@@ -0,0 +1,173 @@
+package tigasdk
+
+import (
+	"context"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/imulab/coldcall"
+	"github.com/imulab/coldcall/body"
+	"github.com/imulab/coldcall/status"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultJwksRefetchCooldown = 30 * time.Second
+
+// remoteKeySet is a jwx.KeySet fetched from Tiga, which can be refreshed on schedule and on demand.
+type remoteKeySet struct {
+	fetch    func(ctx context.Context) (*jwx.KeySet, time.Duration, error)
+	interval time.Duration
+	cooldown time.Duration
+
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	set       *jwx.KeySet
+	ttl       time.Duration
+	fetchedAt time.Time
+}
+
+// current returns the last fetched jwx.KeySet, or nil if it has never been fetched.
+func (k *remoteKeySet) current() *jwx.KeySet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.set
+}
+
+// refresh fetches the jwx.KeySet from remote. Unless force is true, the fetch is skipped if the last
+// successful fetch happened within the cooldown period. Concurrent calls are serialized, so that
+// callers waiting on an in-flight fetch do not fetch again.
+func (k *remoteKeySet) refresh(ctx context.Context, force bool) error {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	k.mu.RLock()
+	fresh := !k.fetchedAt.IsZero() && time.Since(k.fetchedAt) < k.cooldown
+	k.mu.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	set, ttl, err := k.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.set, k.ttl, k.fetchedAt = set, ttl, time.Now()
+	k.mu.Unlock()
+
+	return nil
+}
+
+// run refreshes the jwx.KeySet periodically until stop is closed. The refresh happens every interval, or sooner
+// if the cache headers of the last response indicated so, but never more frequent than the cooldown period.
+// Failed refreshes are ignored and the last known jwx.KeySet remains in use.
+func (k *remoteKeySet) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(k.nextRefresh()):
+			_ = k.refresh(context.Background(), true)
+		}
+	}
+}
+
+func (k *remoteKeySet) nextRefresh() time.Duration {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	d := k.interval
+	if k.ttl > 0 && k.ttl < d {
+		d = k.ttl
+	}
+	if d < k.cooldown {
+		d = k.cooldown
+	}
+	return d
+}
+
+// decode is a wrapper around jwx.Decode that verifies the token with the Tiga jwks. When the key to verify the
+// token cannot be found, for instance, when Tiga has rotated its keys, the jwks is refetched and decoding is
+// attempted once more. The refetch is rate limited by the cooldown period set by WithJwksRefresh.
+func (s *SDK) decode(ctx context.Context, token string, decryptJwks *jwx.KeySet, hint jwx.Algs, dest interface{}) error {
+	jwks, err := s.getTigaJwks(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = jwx.Decode(token, jwks, decryptJwks, hint, dest)
+	if err != jwx.ErrNoVerificationKey {
+		return err
+	}
+
+	if err := s.tigaJwks.refresh(ctx, false); err != nil {
+		return err
+	}
+
+	return jwx.Decode(token, s.tigaJwks.current(), decryptJwks, hint, dest)
+}
+
+func (s *SDK) fetchTigaJwks(ctx context.Context) (*jwx.KeySet, time.Duration, error) {
+	req, err := coldcall.Get(ctx, s.serviceBaseURL+"/.well-known/jwks.json")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var newJwks coldcall.Constructor = func() interface{} {
+		return jwx.NewKeySet()
+	}
+
+	resp := coldcall.Response(s.httpClient.Do(req)).
+		Expect(status.Is200, body.JSONUnmarshal(newJwks))
+
+	set, _, err := resp.Read()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if jwks, ok := set.(*jwx.KeySet); ok {
+		return jwks, cacheLifetime(resp.Original().Header), nil
+	}
+
+	return nil, 0, ErrUnexpectedResponse
+}
+
+// cacheLifetime returns the lifetime of the response as indicated by the "Cache-Control" header, or the "Expires"
+// header in the absence of the former. If neither header indicates a positive lifetime, zero is returned.
+func cacheLifetime(h http.Header) time.Duration {
+	if cc := h.Get("Cache-Control"); len(cc) > 0 {
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.TrimSpace(directive)
+			switch {
+			case directive == "no-cache", directive == "no-store":
+				return 0
+			case strings.HasPrefix(directive, "max-age="):
+				if sec, err := strconv.ParseInt(strings.TrimPrefix(directive, "max-age="), 10, 64); err == nil && sec > 0 {
+					return time.Duration(sec) * time.Second
+				}
+				return 0
+			}
+		}
+	}
+
+	if exp := h.Get("Expires"); len(exp) > 0 {
+		expires, err := http.ParseTime(exp)
+		if err != nil {
+			return 0
+		}
+
+		now := time.Now()
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			now = date
+		}
+
+		if lifetime := expires.Sub(now); lifetime > 0 {
+			return lifetime
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSDK_ProtectRefetchesRotatedJwks(t *testing.T) {
+	var (
+		oldKey  = jwx.GenerateSignatureKey("old", jwx.ES256, 0)
+		newKey  = jwx.GenerateSignatureKey("new", jwx.ES256, 0)
+		rotated int32
+		fetches int32
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(rw).Encode(&oidc.Discovery{Issuer: "https://tiga.test"})
+		case "/.well-known/jwks.json":
+			atomic.AddInt32(&fetches, 1)
+			key := oldKey
+			if atomic.LoadInt32(&rotated) == 1 {
+				key = newKey
+			}
+			_ = json.NewEncoder(rw).Encode(jwx.NewKeySet(key).ToPublic())
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithJwksRefresh(0, time.Nanosecond),
+	)
+	assert.NoError(t, err)
+	defer sdk.Close()
+
+	token, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, jwx.NewKeySet(newKey)), nil, &tigasdk.AccessTokenClaims{
+		Claims: jwt.Claims{
+			Issuer: "https://tiga.test",
+			Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	assert.NoError(t, err)
+
+	handler := sdk.Protect(nil)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() int {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve())
+
+	atomic.StoreInt32(&rotated, 1)
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&fetches))
+}
@@ -0,0 +1,68 @@
+package tigasdk
+
+import (
+	"context"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"strings"
+)
+
+// TokenByJWTBearer acquire access tokens for the subject using the JWT bearer grant, with an assertion signed by
+// the keys set by WithClientJwks. It is intended for trusted backends which act for a subject without an End-User
+// session.
+//
+// The assertion is signed with the algorithm set by WithPrivateKeyJwt if the client jwks has a key for it;
+// otherwise, with the first algorithm in TokenEndpointAuthSigningAlgValuesSupported that the client jwks has a
+// key for. When no such key exists, jwx.ErrNoSigningKey is returned.
+func (s *SDK) TokenByJWTBearer(ctx context.Context, subject string, scopes []string) (*TokenResponse, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var preferred string
+	if s.authMethod == oidc.PrivateKeyJwt {
+		preferred = s.authSigAlg
+	}
+
+	alg, err := s.clientSigAlg(preferred, discovery.TokenEndpointAuthSigningAlgValuesSupported)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, err := s.assertion(jwx.SignatureKeyByAlg(alg, s.clientJwks), subject, discovery.TokenEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":  s.clientId,
+		"grant_type": oidc.GrantTypeJwtBearer,
+		"scope":      strings.Join(scopes, " "),
+		"assertion":  assertion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeTokenRequest(ctx, options)
+}
+
+// clientSigAlg picks the signature algorithm, among the preferred one and the supported ones in order, for
+// which the client jwks has a signing key.
+func (s *SDK) clientSigAlg(preferred string, supported []string) (string, error) {
+	if s.clientJwks == nil {
+		return "", jwx.ErrNoSigningKey
+	}
+
+	for _, alg := range append([]string{preferred}, supported...) {
+		if len(alg) == 0 || jwx.IsNone(alg) {
+			continue
+		}
+		if _, ok := s.clientJwks.KeyForSigning(alg); ok {
+			return alg, nil
+		}
+	}
+
+	return "", jwx.ErrNoSigningKey
+}
@@ -0,0 +1,77 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"testing"
+)
+
+func TestSDK_TokenByJWTBearer(t *testing.T) {
+	clientJwks := jwx.NewKeySet(jwx.GenerateSignatureKey("batch", jwx.ES256, 0))
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, oidc.GrantTypeJwtBearer, r.PostFormValue("grant_type"))
+
+			hd, err := jwx.ReadHeader(r.PostFormValue("assertion"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, jwx.ES256, hd.Alg)
+				assert.Equal(t, "batch", hd.KeyId)
+			}
+
+			claims := new(jwt.Claims)
+			err = jwx.Decode(r.PostFormValue("assertion"), clientJwks.ToPublic(), nil, jwx.Algs{Sig: jwx.ES256}, claims)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "batch-job", claims.Issuer)
+				assert.Equal(t, "user", claims.Subject)
+			}
+
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer"})
+		},
+	})
+	defer srv.Close()
+
+	for _, c := range []struct {
+		name    string
+		options []tigasdk.Option
+	}{
+		{
+			name:    "client_secret_basic",
+			options: []tigasdk.Option{tigasdk.WithClientSecretBasic("batch-job", "secret"), tigasdk.WithClientJwks(clientJwks)},
+		},
+		{
+			name:    "client_secret_jwt",
+			options: []tigasdk.Option{tigasdk.WithClientSecretJwt("batch-job", "a-sufficiently-long-client-secret", ""), tigasdk.WithClientJwks(clientJwks)},
+		},
+		{
+			name:    "private_key_jwt without a key for its algorithm",
+			options: []tigasdk.Option{tigasdk.WithPrivateKeyJwt("batch-job", clientJwks, jwx.RS256)},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			sdk, err := tigasdk.NewWithContext(context.Background(), append([]tigasdk.Option{tigasdk.WithServiceBaseURL(srv.URL)}, c.options...)...)
+			assert.NoError(t, err)
+
+			tr, err := sdk.TokenByJWTBearer(context.Background(), "user", []string{"foo"})
+			if assert.NoError(t, err) {
+				assert.Equal(t, "token", tr.AccessToken)
+			}
+		})
+	}
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("batch-job", "secret"),
+	)
+	assert.NoError(t, err)
+
+	_, err = sdk.TokenByJWTBearer(context.Background(), "user", []string{"foo"})
+	assert.Equal(t, jwx.ErrNoSigningKey, err)
+}
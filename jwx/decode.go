@@ -30,38 +30,11 @@ func Decode(jwx string, verifyJwks *KeySet, decryptJwks *KeySet, hint Algs, dest
 	}
 
 	if !IsNone(hint.Encrypt) && !IsNone(hint.Encode) {
-		jwe, err := jose.ParseEncrypted(string(raw))
+		decrypted, err := Decrypt(string(raw), decryptJwks)
 		if err != nil {
 			return err
 		}
-
-		key, err := func() (*Key, error) {
-			switch {
-			case len(jwe.Header.KeyID) > 0:
-				if k, ok := decryptJwks.KeyById(jwe.Header.KeyID); ok {
-					return k, nil
-				} else {
-					return nil, ErrNoDecryptionKey
-				}
-			case len(jwe.Header.Algorithm) > 0:
-				if k, ok := decryptJwks.KeyForEncryption(jwe.Header.Algorithm); ok {
-					return k, nil
-				} else {
-					return nil, ErrNoDecryptionKey
-				}
-			default:
-				return nil, ErrNoDecryptionKey
-			}
-		}()
-		if err != nil {
-			return err
-		}
-
-		if decrypted, err := jwe.Decrypt(key.Raw()); err != nil {
-			return err
-		} else {
-			raw = decrypted
-		}
+		raw = decrypted
 	}
 
 	if !IsNone(hint.Sig) {
@@ -107,3 +80,42 @@ func Decode(jwx string, verifyJwks *KeySet, decryptJwks *KeySet, hint Algs, dest
 
 	return json.Unmarshal(raw, dest)
 }
+
+// Decrypt decrypts the given JWE token with a key from decryptJwks, and returns the decrypted payload. The key is
+// resolved by the "kid" header, or the "alg" header if "kid" is absent. A key resolved by "kid" is only used with
+// the "alg" it declares, if any. The decrypted payload may be a nested JWS token, which can be further processed by
+// Decode.
+func Decrypt(jwx string, decryptJwks *KeySet) ([]byte, error) {
+	if decryptJwks == nil {
+		decryptJwks = NewKeySet()
+	}
+
+	jwe, err := jose.ParseEncrypted(jwx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := func() (*Key, error) {
+		switch {
+		case len(jwe.Header.KeyID) > 0:
+			if k, ok := decryptJwks.KeyById(jwe.Header.KeyID); ok && (len(k.Alg()) == 0 || k.Alg() == jwe.Header.Algorithm) {
+				return k, nil
+			} else {
+				return nil, ErrNoDecryptionKey
+			}
+		case len(jwe.Header.Algorithm) > 0:
+			if k, ok := decryptJwks.KeyForEncryption(jwe.Header.Algorithm); ok {
+				return k, nil
+			} else {
+				return nil, ErrNoDecryptionKey
+			}
+		default:
+			return nil, ErrNoDecryptionKey
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	return jwe.Decrypt(key.Raw())
+}
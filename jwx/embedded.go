@@ -0,0 +1,83 @@
+package jwx
+
+import (
+	"encoding/json"
+	"errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+var (
+	ErrInvalidEmbeddedKey = errors.New("embedded jwk is missing or is not an asymmetric public key")
+)
+
+// EncodeWithJwk signs the payload with the asymmetric key and its algorithm, embedding the public portion of
+// the key in the "jwk" header instead of the "kid" header, and sets the "typ" header if typ is not empty. Such
+// tokens are self-contained proofs of possession of the key, for instance, DPoP proofs.
+func EncodeWithJwk(key *Key, typ string, payload interface{}) (string, error) {
+	if key == nil || key.IsSymmetric() {
+		return "", ErrInvalidEmbeddedKey
+	}
+	if err := ValidSignatureAlg(key.Alg()); err != nil || IsNone(key.Alg()) {
+		return "", ErrInvalidSignatureAlg
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	opts := &jose.SignerOptions{EmbedJWK: true}
+	if len(typ) > 0 {
+		opts = opts.WithType(jose.ContentType(typ))
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.SignatureAlgorithm(key.Alg()),
+		Key:       key.Raw(),
+	}, opts)
+	if err != nil {
+		return "", err
+	}
+
+	jws, err := signer.Sign(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return jws.CompactSerialize()
+}
+
+// DecodeWithJwk verifies the JWS token with the public key embedded in its "jwk" header, and decodes the payload
+// into the destination object. The embedded key is returned, so that the caller can establish trust in it, for
+// instance, by comparing its Thumbprint with a known value. Note that a valid signature only proves possession
+// of the embedded key.
+func DecodeWithJwk(token string, dest interface{}) (*Key, error) {
+	jws, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, ErrInvalidJwxToken
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, ErrInvalidJwxToken
+	}
+
+	jwk := jws.Signatures[0].Header.JSONWebKey
+	if jwk == nil || !jwk.Valid() {
+		return nil, ErrInvalidEmbeddedKey
+	}
+
+	key := &Key{key: jwk}
+	if key.IsSymmetric() || !key.IsPublic() {
+		return nil, ErrInvalidEmbeddedKey
+	}
+
+	payload, err := jws.Verify(key.Raw())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(payload, dest); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,34 @@
+package jwx_test
+
+import (
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncodeWithJwk(t *testing.T) {
+	key := jwx.GenerateSignatureKey("proof", jwx.ES256, 0)
+
+	token, err := jwx.EncodeWithJwk(key, "dpop+jwt", map[string]interface{}{"htm": "GET"})
+	assert.NoError(t, err)
+
+	header, err := jwx.ReadHeader(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "dpop+jwt", header.Type)
+	assert.Empty(t, header.KeyId)
+
+	var claims map[string]interface{}
+	embedded, err := jwx.DecodeWithJwk(token, &claims)
+	assert.NoError(t, err)
+	assert.Equal(t, "GET", claims["htm"])
+	assert.True(t, embedded.IsPublic())
+
+	expected, err := key.Thumbprint()
+	assert.NoError(t, err)
+	actual, err := embedded.Thumbprint()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	_, err = jwx.EncodeWithJwk(jwx.NewSymmetricKey("", jwx.HS256, jwx.UseSig, []byte("secret")), "", nil)
+	assert.Equal(t, jwx.ErrInvalidEmbeddedKey, err)
+}
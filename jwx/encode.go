@@ -29,7 +29,8 @@ func EncodeToString(sig KeySource, enc KeySource, payload interface{}) (string,
 // The signature KeySource sig and encryption KeySource enc controls the signing and encryption operations. Both stage
 // can be skipped by providing nil or SkipKeySource as the KeySource for the corresponding stage. Normal usages would
 // be to skip none or just one of the stages. However, it is fine to skip both stages, which simply reduces this function
-// to a JSON encoding function.
+// to a JSON encoding function. When both stages are performed, the JWE token is marked with the "cty" header of
+// ContentTypeJwt, as it contains a nested JWT.
 func Encode(sig KeySource, enc KeySource, payload interface{}) ([]byte, error) {
 	if sig == nil {
 		sig = SkipKeySource
@@ -58,11 +59,17 @@ func Encode(sig KeySource, enc KeySource, payload interface{}) ([]byte, error) {
 	if !ok {
 		return nil, ErrNoSigningKey
 	}
-	if !IsNone(algs.Sig) {
+	nested := !IsNone(algs.Sig)
+	if nested {
+		opts := &jose.SignerOptions{}
+		if len(sigKey.Id()) > 0 {
+			opts = opts.WithHeader("kid", sigKey.Id())
+		}
+
 		signer, err := jose.NewSigner(jose.SigningKey{
 			Algorithm: jose.SignatureAlgorithm(algs.Sig),
 			Key:       sigKey.Raw(),
-		}, (&jose.SignerOptions{}).WithHeader("kid", sigKey.Id()))
+		}, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -85,11 +92,19 @@ func Encode(sig KeySource, enc KeySource, payload interface{}) ([]byte, error) {
 		return nil, ErrNoEncryptionKey
 	}
 	if !IsNone(algs.Encrypt) && !IsNone(algs.Encode) {
+		opts := &jose.EncrypterOptions{}
+		if len(encKey.Id()) > 0 {
+			opts = opts.WithHeader("kid", encKey.Id())
+		}
+		if nested {
+			opts = opts.WithContentType(ContentTypeJwt)
+		}
+
 		encrypter, err := jose.NewEncrypter(jose.ContentEncryption(algs.Encode), jose.Recipient{
 			Algorithm: jose.KeyAlgorithm(algs.Encrypt),
 			Key:       encKey.ToPublic().Raw(),
 			KeyID:     encKey.Id(),
-		}, (&jose.EncrypterOptions{}).WithHeader("kid", encKey.Id()))
+		}, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,61 @@
+package jwx_test
+
+import (
+	"encoding/base64"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestEncode_KeyId(t *testing.T) {
+	rawHeader := func(t *testing.T, token string) string {
+		raw, err := base64.RawURLEncoding.DecodeString(token[:strings.IndexByte(token, '.')])
+		assert.NoError(t, err)
+		return string(raw)
+	}
+
+	for _, c := range []struct {
+		name   string
+		sig    *jwx.Key
+		enc    *jwx.Key
+		hasKid bool
+	}{
+		{name: "signed with kid", sig: jwx.GenerateSignatureKey("k1", jwx.ES256, 0), hasKid: true},
+		{name: "signed without kid", sig: jwx.NewSymmetricKey("", jwx.HS256, jwx.UseSig, []byte("a-sufficiently-long-secret"))},
+		{
+			name:   "encrypted with kid",
+			sig:    jwx.GenerateSignatureKey("k1", jwx.ES256, 0),
+			enc:    jwx.NewSymmetricKey("k2", jwx.A256GCMKW, jwx.UseEnc, []byte(strings.Repeat("k", 32))),
+			hasKid: true,
+		},
+		{
+			name: "encrypted without kid",
+			sig:  jwx.GenerateSignatureKey("k1", jwx.ES256, 0),
+			enc:  jwx.NewSymmetricKey("", jwx.A256GCMKW, jwx.UseEnc, []byte(strings.Repeat("k", 32))),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			enc := jwx.SkipKeySource
+			if c.enc != nil {
+				enc = jwx.EncryptionKeyByAlg(jwx.A256GCMKW, jwx.A256GCM, jwx.NewKeySet(c.enc))
+			}
+
+			token, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(c.sig.Alg(), jwx.NewKeySet(c.sig)), enc, map[string]interface{}{"sub": "foo"})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if c.hasKid {
+				assert.Contains(t, rawHeader(t, token), `"kid"`)
+			} else {
+				assert.NotContains(t, rawHeader(t, token), `"kid"`)
+			}
+
+			if c.enc != nil {
+				_, err = jwx.Decrypt(token, jwx.NewKeySet(c.enc))
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package jwx
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+)
+
+// HalfHash computes the base64 url encoding of the left-most half of the hash of value, where the hash algorithm
+// is the one used by the signature algorithm alg. This is the algorithm to compute "at_hash" and "c_hash" claims
+// in the id token. If alg is not a supported signature algorithm, ErrInvalidSignatureAlg is returned.
+//
+// https://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken
+func HalfHash(alg string, value string) (string, error) {
+	var hasher hash.Hash
+	switch alg {
+	case HS256, RS256, PS256, ES256:
+		hasher = sha256.New()
+	case HS384, RS384, PS384, ES384:
+		hasher = sha512.New384()
+	case HS512, RS512, PS512, ES512:
+		hasher = sha512.New()
+	default:
+		return "", ErrInvalidSignatureAlg
+	}
+
+	hasher.Write([]byte(value))
+	sum := hasher.Sum(nil)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
@@ -0,0 +1,52 @@
+package jwx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// ContentTypeJwt is the "cty" header value of a JWE token whose payload is a nested JWT.
+const ContentTypeJwt = "JWT"
+
+// Header is the common JOSE header of a JWS or JWE token.
+type Header struct {
+	// Alg is the "alg" header, which is the signature algorithm for JWS, or
+	// the key encryption algorithm for JWE.
+	Alg string `json:"alg,omitempty"`
+	// Enc is the "enc" header, which is the content encryption algorithm. It is
+	// only present for JWE.
+	Enc string `json:"enc,omitempty"`
+	// KeyId is the "kid" header.
+	KeyId string `json:"kid,omitempty"`
+	// Type is the "typ" header.
+	Type string `json:"typ,omitempty"`
+	// ContentType is the "cty" header.
+	ContentType string `json:"cty,omitempty"`
+}
+
+// IsEncrypted returns true if the header belongs to a JWE token.
+func (h *Header) IsEncrypted() bool {
+	return len(h.Enc) > 0
+}
+
+// ReadHeader reads the protected header of the compact serialized JWS or JWE token, without verifying
+// or decrypting the token.
+func ReadHeader(token string) (*Header, error) {
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return nil, ErrInvalidJwxToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token[:i])
+	if err != nil {
+		return nil, ErrInvalidJwxToken
+	}
+
+	h := new(Header)
+	if err := json.Unmarshal(raw, h); err != nil {
+		return nil, ErrInvalidJwxToken
+	}
+
+	return h, nil
+}
@@ -1,9 +1,11 @@
 package jwx
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
+	"encoding/base64"
 	"github.com/absurdlab/tiga-go-sdk/jwx/internal"
 	"gopkg.in/square/go-jose.v2"
 )
@@ -80,9 +82,32 @@ func (k *Key) ToPublic() *Key {
 	}
 }
 
+// Thumbprint returns the base64 url encoded SHA-256 JWK thumbprint (RFC 7638) of the key. The thumbprint of a
+// private key is the same as that of its public key.
+func (k *Key) Thumbprint() (string, error) {
+	tp, err := k.key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tp), nil
+}
+
 // KeySource is a function that can produce a Key and its corresponding algorithm specs.
 type KeySource func() (*Key, Algs, bool)
 
+// NewSymmetricKey creates a symmetric Key with the given kid, algorithm and usage from the raw secret. It is
+// typically used to create a HMAC signing key from a client secret, or a key wrapping key from a shared secret.
+func NewSymmetricKey(kid string, alg string, use string, secret []byte) *Key {
+	return &Key{
+		key: &jose.JSONWebKey{
+			Key:       secret,
+			KeyID:     kid,
+			Algorithm: alg,
+			Use:       use,
+		},
+	}
+}
+
 // GenerateSignatureKey generates a signature Key with the given kid and algorithm.
 func GenerateSignatureKey(kid string, alg string, bits int) *Key {
 	_, privKey, err := internal.KeygenSig(jose.SignatureAlgorithm(alg), bits)
@@ -0,0 +1,118 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrEndSessionNotSupported = errors.New("end session endpoint is not available")
+)
+
+// LogoutRequest is the parameters of RP-initiated logout at the end session endpoint.
+type LogoutRequest struct {
+	// IdTokenHint is the optional id token previously issued to the End-User, as a hint about the session.
+	IdTokenHint string
+
+	// PostLogoutRedirectURI is the optional registered uri to which the End-User is redirected after logout.
+	PostLogoutRedirectURI string
+
+	// UILocales is the optional space delimited list of preferred languages for the logout pages.
+	UILocales string
+}
+
+// LogoutURL builds the url to the end session endpoint to which the End-User should be redirected to log out at
+// Tiga. When PostLogoutRedirectURI is set, a "state" is generated and returned in the AuthorizeSession, whose
+// RedirectURI is the post logout redirect uri. The session should be saved in the StateStore given to
+// PostLogoutHandler.
+func (s *SDK) LogoutURL(ctx context.Context, req *LogoutRequest) (string, *AuthorizeSession, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(discovery.EndSessionEndpoint) == 0 {
+		return "", nil, ErrEndSessionNotSupported
+	}
+
+	params := url.Values{}
+	params.Set("client_id", s.clientId)
+
+	var session *AuthorizeSession
+	if len(req.PostLogoutRedirectURI) > 0 {
+		session = &AuthorizeSession{
+			State:       internal.RandomString(16),
+			RedirectURI: req.PostLogoutRedirectURI,
+			CreatedAt:   time.Now().Unix(),
+		}
+		params.Set("post_logout_redirect_uri", req.PostLogoutRedirectURI)
+		params.Set("state", session.State)
+	}
+
+	if len(req.IdTokenHint) > 0 {
+		params.Set("id_token_hint", req.IdTokenHint)
+	}
+	if len(req.UILocales) > 0 {
+		params.Set("ui_locales", req.UILocales)
+	}
+
+	logoutURL, err := buildURL(discovery.EndSessionEndpoint, params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return logoutURL, session, nil
+}
+
+// PostLogoutOpt is the options for PostLogoutHandler.
+type PostLogoutOpt struct {
+	// StateStore loads the AuthorizeSession returned by LogoutURL. It is required.
+	StateStore StateStore
+
+	// RenderSuccess renders the response after the state is validated. When nil, the End-User is redirected
+	// to "/".
+	RenderSuccess func(rw http.ResponseWriter, r *http.Request, session *AuthorizeSession)
+
+	// RenderError renders the response when the state is missing or unknown. When nil, a 400 status is written.
+	RenderError func(rw http.ResponseWriter, r *http.Request, err error)
+}
+
+// PostLogoutHandler returns a http.Handler for the post logout redirect uri, which takes the AuthorizeSession of
+// the returning "state" from the StateStore. Requests without state, or with a state not found in the StateStore,
+// are rejected with ErrMissingState and ErrStateNotFound.
+func (s *SDK) PostLogoutHandler(opt *PostLogoutOpt) http.Handler {
+	if opt == nil || opt.StateStore == nil {
+		panic("tigasdk: PostLogoutOpt.StateStore is required")
+	}
+
+	if opt.RenderSuccess == nil {
+		opt.RenderSuccess = func(rw http.ResponseWriter, r *http.Request, session *AuthorizeSession) {
+			http.Redirect(rw, r, "/", http.StatusFound)
+		}
+	}
+	if opt.RenderError == nil {
+		opt.RenderError = func(rw http.ResponseWriter, r *http.Request, err error) {
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		state := r.URL.Query().Get("state")
+		if len(state) == 0 {
+			opt.RenderError(rw, r, ErrMissingState)
+			return
+		}
+
+		session, err := opt.StateStore.Take(r.Context(), state)
+		if err != nil {
+			opt.RenderError(rw, r, err)
+			return
+		}
+
+		opt.RenderSuccess(rw, r, session)
+	})
+}
@@ -0,0 +1,74 @@
+package tigasdk_test
+
+import (
+	"context"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSDK_LogoutURL(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), nil)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+	)
+	assert.NoError(t, err)
+
+	raw, session, err := sdk.LogoutURL(context.Background(), &tigasdk.LogoutRequest{
+		IdTokenHint:           "id-token",
+		PostLogoutRedirectURI: "https://client.test/logged_out",
+		UILocales:             "en-US",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u, err := url.Parse(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, srv.URL+"/oauth/logout", u.Scheme+"://"+u.Host+u.Path)
+
+	q := u.Query()
+	assert.Equal(t, "client", q.Get("client_id"))
+	assert.Equal(t, "id-token", q.Get("id_token_hint"))
+	assert.Equal(t, "https://client.test/logged_out", q.Get("post_logout_redirect_uri"))
+	assert.Equal(t, "en-US", q.Get("ui_locales"))
+	assert.Equal(t, session.State, q.Get("state"))
+	assert.NotEmpty(t, session.State)
+
+	t.Run("without redirect", func(t *testing.T) {
+		raw, session, err := sdk.LogoutURL(context.Background(), &tigasdk.LogoutRequest{})
+		assert.NoError(t, err)
+		assert.Nil(t, session)
+		assert.NotContains(t, raw, "state=")
+	})
+
+	t.Run("post logout handler", func(t *testing.T) {
+		store := tigasdk.NewMemoryStateStore(0, 0)
+		assert.NoError(t, store.Save(context.Background(), session))
+
+		handler := sdk.PostLogoutHandler(&tigasdk.PostLogoutOpt{StateStore: store})
+
+		for _, c := range []struct {
+			name   string
+			target string
+			status int
+		}{
+			{name: "valid state", target: "/logged_out?state=" + session.State, status: http.StatusFound},
+			{name: "state reused", target: "/logged_out?state=" + session.State, status: http.StatusBadRequest},
+			{name: "missing state", target: "/logged_out", status: http.StatusBadRequest},
+		} {
+			t.Run(c.name, func(t *testing.T) {
+				rw := httptest.NewRecorder()
+				handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, c.target, nil))
+				assert.Equal(t, c.status, rw.Code)
+			})
+		}
+	})
+}
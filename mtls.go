@@ -0,0 +1,67 @@
+package tigasdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedTransport       = errors.New("client certificate requires the http client to use *http.Transport")
+	ErrCertificateBindingMismatch = errors.New("access token is not bound to the client certificate")
+)
+
+// withClientCertificate returns a copy of the http client, whose transport presents the client certificate
+// during TLS handshakes. The original http client is not modified.
+func withClientCertificate(client *http.Client, cert tls.Certificate) (*http.Client, error) {
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, ErrUnsupportedTransport
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+
+	cp := *client
+	cp.Transport = transport
+
+	return &cp, nil
+}
+
+// isMtls returns true if the client authenticates at Tiga with a client certificate.
+func (s *SDK) isMtls() bool {
+	return s.authMethod == oidc.TLSClientAuth || s.authMethod == oidc.SelfSignedTLSClientAuth
+}
+
+// getEndpoints returns the discovery document to resolve the endpoints to call. When the client authenticates
+// with a client certificate, endpoints are replaced by their mutual TLS aliases advertised by Tiga.
+func (s *SDK) getEndpoints(ctx context.Context) (*oidc.Discovery, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isMtls() && discovery.MtlsEndpointAliases != nil {
+		return discovery.WithMtlsEndpointAliases(), nil
+	}
+
+	return discovery, nil
+}
+
+// certificateThumbprint computes the "x5t#S256" confirmation of the certificate.
+func certificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,135 @@
+package tigasdk_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSDK_MutualTLS(t *testing.T) {
+	var (
+		tigaJwks   = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		clientCert = newSelfSignedCertificate(t, "client")
+		issuer     = "https://tiga.test"
+	)
+
+	thumbprint := func(cert *x509.Certificate) string {
+		sum := sha256.Sum256(cert.Raw)
+		return base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	mtlsSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oauth/token", r.URL.Path)
+		assert.Equal(t, "client", r.PostFormValue("client_id"))
+		if !assert.Len(t, r.TLS.PeerCertificates, 1) {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		accessToken, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, &tigasdk.AccessTokenClaims{
+			Claims: jwt.Claims{
+				Issuer: issuer,
+				Expiry: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Cnf: &tigasdk.Confirmation{X5tS256: thumbprint(r.TLS.PeerCertificates[0])},
+		})
+		assert.NoError(t, err)
+
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: accessToken, TokenType: "Bearer"})
+	}))
+	mtlsSrv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	mtlsSrv.StartTLS()
+	defer mtlsSrv.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(rw).Encode(&oidc.Discovery{
+				Issuer:        issuer,
+				TokenEndpoint: issuer + "/oauth/token",
+				MtlsEndpointAliases: &oidc.MtlsEndpointAliases{
+					TokenEndpoint: mtlsSrv.URL + "/oauth/token",
+				},
+			})
+		case "/.well-known/jwks.json":
+			_ = json.NewEncoder(rw).Encode(tigaJwks.ToPublic())
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	httpClient := mtlsSrv.Client()
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithSelfSignedTLSClientAuth("client", clientCert),
+		tigasdk.WithHTTPClient(httpClient),
+	)
+	assert.NoError(t, err)
+	assert.Empty(t, httpClient.Transport.(*http.Transport).TLSClientConfig.Certificates)
+
+	tr, err := sdk.TokenByClientCredentials(context.Background(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	handler := sdk.Protect(&tigasdk.ProtectOpt{CertificateBound: true})(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(cert *x509.Certificate) int {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+tr.AccessToken)
+		if cert != nil {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		}
+		handler.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	otherCert := newSelfSignedCertificate(t, "other")
+	assert.Equal(t, http.StatusOK, serve(clientCert.Leaf))
+	assert.Equal(t, http.StatusUnauthorized, serve(otherCert.Leaf))
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+}
+
+func newSelfSignedCertificate(t *testing.T, cn string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
@@ -7,4 +7,8 @@ const (
 	ClaimAmr      = "amr"
 	ClaimAcr      = "acr"
 	ClaimAzp      = "azp"
+	ClaimAtHash   = "at_hash"
+	ClaimCHash    = "c_hash"
+	ClaimAct      = "act"
+	ClaimCnf      = "cnf"
 )
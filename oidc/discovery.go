@@ -52,6 +52,20 @@ type Discovery struct {
 	RequireRequestURIRegistration              *bool    `json:"require_request_uri_registration"`
 	OPPolicyURI                                string   `json:"op_policy_uri"`
 	OPTermsOfServiceURI                        string   `json:"op_tos_uri"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                         string   `json:"revocation_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint                string   `json:"device_authorization_endpoint,omitempty"`
+	TLSClientCertificateBoundAccessTokens      bool     `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	PushedAuthorizationRequestEndpoint         string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	RequirePushedAuthorizationRequests         bool     `json:"require_pushed_authorization_requests,omitempty"`
+	AuthorizationSigningAlgValuesSupported     []string `json:"authorization_signing_alg_values_supported,omitempty"`
+	AuthorizationEncryptionAlgValuesSupported  []string `json:"authorization_encryption_alg_values_supported,omitempty"`
+	AuthorizationEncryptionEncValuesSupported  []string `json:"authorization_encryption_enc_values_supported,omitempty"`
+	EndSessionEndpoint                         string   `json:"end_session_endpoint,omitempty"`
+
+	// MtlsEndpointAliases is the alternative endpoints which the client shall use instead of the
+	// default ones when authenticating with mutual TLS.
+	MtlsEndpointAliases *MtlsEndpointAliases `json:"mtls_endpoint_aliases,omitempty"`
 
 	// AuthorizeResumeEndpoint is the endpoint where OP can resume processing of the
 	// original authorize request. This HTTP GET endpoint accepts a single "challenge"
@@ -238,6 +252,26 @@ func (d *Discovery) IdTokenLifespanDuration() time.Duration {
 	return time.Duration(d.IdTokenLifespan) * time.Second
 }
 
+// WithMtlsEndpointAliases returns a copy of the Discovery, whose endpoints are replaced by their mutual TLS
+// aliases, if any.
+func (d *Discovery) WithMtlsEndpointAliases() *Discovery {
+	cp := d.Clone()
+	if a := d.MtlsEndpointAliases; a != nil {
+		replace := func(endpoint *string, alias string) {
+			if len(alias) > 0 {
+				*endpoint = alias
+			}
+		}
+		replace(&cp.TokenEndpoint, a.TokenEndpoint)
+		replace(&cp.UserInfoEndpoint, a.UserInfoEndpoint)
+		replace(&cp.IntrospectionEndpoint, a.IntrospectionEndpoint)
+		replace(&cp.RevocationEndpoint, a.RevocationEndpoint)
+		replace(&cp.DeviceAuthorizationEndpoint, a.DeviceAuthorizationEndpoint)
+		replace(&cp.PushedAuthorizationRequestEndpoint, a.PushedAuthorizationRequestEndpoint)
+	}
+	return cp
+}
+
 // Clone returns a new Discovery object with the same parameters.
 func (d *Discovery) Clone() *Discovery {
 	return &Discovery{
@@ -275,6 +309,17 @@ func (d *Discovery) Clone() *Discovery {
 		RequireRequestURIRegistration:              internal.CopyBool(d.RequireRequestURIRegistration),
 		OPPolicyURI:                                d.OPPolicyURI,
 		OPTermsOfServiceURI:                        d.OPTermsOfServiceURI,
+		IntrospectionEndpoint:                      d.IntrospectionEndpoint,
+		RevocationEndpoint:                         d.RevocationEndpoint,
+		DeviceAuthorizationEndpoint:                d.DeviceAuthorizationEndpoint,
+		TLSClientCertificateBoundAccessTokens:      d.TLSClientCertificateBoundAccessTokens,
+		PushedAuthorizationRequestEndpoint:         d.PushedAuthorizationRequestEndpoint,
+		RequirePushedAuthorizationRequests:         d.RequirePushedAuthorizationRequests,
+		AuthorizationSigningAlgValuesSupported:     internal.CopyArray(d.AuthorizationSigningAlgValuesSupported),
+		AuthorizationEncryptionAlgValuesSupported:  internal.CopyArray(d.AuthorizationEncryptionAlgValuesSupported),
+		AuthorizationEncryptionEncValuesSupported:  internal.CopyArray(d.AuthorizationEncryptionEncValuesSupported),
+		EndSessionEndpoint:                         d.EndSessionEndpoint,
+		MtlsEndpointAliases:                        d.MtlsEndpointAliases.clone(),
 		AuthorizeResumeEndpoint:                    d.AuthorizeResumeEndpoint,
 		LoginEndpoint:                              d.LoginEndpoint,
 		SelectAccountEndpoint:                      d.SelectAccountEndpoint,
@@ -287,3 +332,21 @@ func (d *Discovery) Clone() *Discovery {
 		AccessTokenSigningAlgValue:                 d.AccessTokenSigningAlgValue,
 	}
 }
+
+// MtlsEndpointAliases is the set of endpoints to use instead of the default ones for mutual TLS.
+type MtlsEndpointAliases struct {
+	TokenEndpoint                      string `json:"token_endpoint,omitempty"`
+	UserInfoEndpoint                   string `json:"userinfo_endpoint,omitempty"`
+	IntrospectionEndpoint              string `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                 string `json:"revocation_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint        string `json:"device_authorization_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+}
+
+func (a *MtlsEndpointAliases) clone() *MtlsEndpointAliases {
+	if a == nil {
+		return nil
+	}
+	cp := *a
+	return &cp
+}
@@ -0,0 +1,13 @@
+package oidc
+
+// Error codes returned in the "error" parameter of error responses, which the client is expected to act upon.
+const (
+	ErrorCodeInvalidRequest       = "invalid_request"
+	ErrorCodeInvalidGrant         = "invalid_grant"
+	ErrorCodeAccessDenied         = "access_denied"
+	ErrorCodeAuthorizationPending = "authorization_pending"
+	ErrorCodeSlowDown             = "slow_down"
+	ErrorCodeExpiredToken         = "expired_token"
+	ErrorCodeInvalidDPoPProof     = "invalid_dpop_proof"
+	ErrorCodeUseDPoPNonce         = "use_dpop_nonce"
+)
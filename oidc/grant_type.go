@@ -8,6 +8,9 @@ const (
 	GrantTypePassword          = "password"
 	GrantTypeClientCredentials = "client_credentials"
 	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	GrantTypeJwtBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
 )
 
 var (
@@ -18,6 +21,9 @@ var (
 		GrantTypePassword,
 		GrantTypeClientCredentials,
 		GrantTypeRefreshToken,
+		GrantTypeDeviceCode,
+		GrantTypeTokenExchange,
+		GrantTypeJwtBearer,
 	}
 
 	// ErrInvalidGrantType indicates an invalid grant type value.
@@ -30,7 +36,10 @@ var (
 			GrantTypeImplicit,
 			GrantTypePassword,
 			GrantTypeClientCredentials,
-			GrantTypeRefreshToken:
+			GrantTypeRefreshToken,
+			GrantTypeDeviceCode,
+			GrantTypeTokenExchange,
+			GrantTypeJwtBearer:
 			return nil
 		default:
 			return ErrInvalidGrantType
@@ -1,6 +1,11 @@
 package oidc
 
-import "errors"
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+)
 
 const (
 	CodeChallengeMethodPlain = "plain"
@@ -11,6 +16,9 @@ var (
 	// ErrInvalidCodeChallengeMethod indicates an invalid code challenge method value.
 	ErrInvalidCodeChallengeMethod = errors.New("code_challenge_method is invalid")
 
+	// ErrInvalidCodeVerifier indicates an invalid code verifier value.
+	ErrInvalidCodeVerifier = errors.New("code_verifier is invalid")
+
 	// ValidCodeChallengeMethod is the validation function for a string containing a code challenge method.
 	ValidCodeChallengeMethod = func(s string) error {
 		switch s {
@@ -20,4 +28,60 @@ var (
 			return ErrInvalidCodeChallengeMethod
 		}
 	}
+
+	// ValidCodeVerifier is the validation function for a string containing a code verifier. A valid code verifier
+	// is between 43 and 128 characters long and only contains unreserved characters.
+	//
+	//	code-verifier = 43*128unreserved
+	//	unreserved = ALPHA / DIGIT / "-" / "." / "_" / "~"
+	//
+	// https://tools.ietf.org/html/rfc7636#section-4.1
+	ValidCodeVerifier = func(s string) error {
+		if len(s) < 43 || len(s) > 128 {
+			return ErrInvalidCodeVerifier
+		}
+		for _, r := range s {
+			switch {
+			case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9',
+				r == '-', r == '.', r == '_', r == '~':
+				continue
+			default:
+				return ErrInvalidCodeVerifier
+			}
+		}
+		return nil
+	}
 )
+
+// NewCodeVerifier generates a new code verifier from 32 cryptographically random bytes, as recommended by the
+// specification. The result is a 43 characters long base64 url encoded string.
+//
+// https://tools.ietf.org/html/rfc7636#section-4.1
+func NewCodeVerifier() string {
+	return internal.RandomString(32)
+}
+
+// CodeChallenge derives the code challenge from the code verifier using the given code challenge method.
+//
+//	plain:
+//	code_challenge = code_verifier
+//
+//	S256:
+//	code_challenge = BASE64URL-ENCODE(SHA256(ASCII(code_verifier)))
+//
+// https://tools.ietf.org/html/rfc7636#section-4.2
+func CodeChallenge(verifier string, method string) (string, error) {
+	if err := ValidCodeVerifier(verifier); err != nil {
+		return "", err
+	}
+
+	switch method {
+	case CodeChallengeMethodPlain:
+		return verifier, nil
+	case CodeChallengeMethodS256:
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+	default:
+		return "", ErrInvalidCodeChallengeMethod
+	}
+}
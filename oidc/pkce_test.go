@@ -0,0 +1,23 @@
+package oidc_test
+
+import (
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	// test vector from https://tools.ietf.org/html/rfc7636#appendix-B
+	challenge, err := oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", oidc.CodeChallengeMethodS256)
+	assert.NoError(t, err)
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", challenge)
+
+	_, err = oidc.CodeChallenge("too-short", oidc.CodeChallengeMethodS256)
+	assert.Equal(t, oidc.ErrInvalidCodeVerifier, err)
+}
+
+func TestNewCodeVerifier(t *testing.T) {
+	verifier := oidc.NewCodeVerifier()
+	assert.NoError(t, oidc.ValidCodeVerifier(verifier))
+	assert.NotEqual(t, verifier, oidc.NewCodeVerifier())
+}
@@ -3,8 +3,13 @@ package oidc
 import "errors"
 
 const (
-	ResponseModeQuery    = "query"
-	ResponseModeFragment = "fragment"
+	ResponseModeQuery       = "query"
+	ResponseModeFragment    = "fragment"
+	ResponseModeFormPost    = "form_post"
+	ResponseModeQueryJwt    = "query.jwt"
+	ResponseModeFragmentJwt = "fragment.jwt"
+	ResponseModeFormPostJwt = "form_post.jwt"
+	ResponseModeJwt         = "jwt"
 )
 
 var (
@@ -14,7 +19,13 @@ var (
 	// ValidResponseType is the validation function for a string response mode.
 	ValidResponseMode = func(s string) error {
 		switch s {
-		case ResponseModeQuery, ResponseModeFragment:
+		case ResponseModeQuery,
+			ResponseModeFragment,
+			ResponseModeFormPost,
+			ResponseModeQueryJwt,
+			ResponseModeFragmentJwt,
+			ResponseModeFormPostJwt,
+			ResponseModeJwt:
 			return nil
 		default:
 			return ErrInvalidResponseMode
@@ -8,6 +8,9 @@ const (
 	ClientSecretJwt   = "client_secret_jwt"
 	PrivateKeyJwt     = "private_key_jwt"
 	TokenAuthNone     = "none"
+
+	TLSClientAuth           = "tls_client_auth"
+	SelfSignedTLSClientAuth = "self_signed_tls_client_auth"
 )
 
 var (
@@ -18,7 +21,8 @@ var (
 	// a valid client token endpoint authentication method.
 	ValidTokenEndpointAuthMethod = func(s string) error {
 		switch s {
-		case ClientSecretBasic, ClientSecretPost, ClientSecretJwt, PrivateKeyJwt, TokenAuthNone:
+		case ClientSecretBasic, ClientSecretPost, ClientSecretJwt, PrivateKeyJwt, TokenAuthNone,
+			TLSClientAuth, SelfSignedTLSClientAuth:
 			return nil
 		default:
 			return ErrInvalidTokenEndpointAuthMethod
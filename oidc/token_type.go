@@ -0,0 +1,33 @@
+package oidc
+
+import "errors"
+
+// Token type identifiers used in token exchange.
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIdToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJwt          = "urn:ietf:params:oauth:token-type:jwt"
+	TokenTypeSaml1        = "urn:ietf:params:oauth:token-type:saml1"
+	TokenTypeSaml2        = "urn:ietf:params:oauth:token-type:saml2"
+)
+
+var (
+	// ErrInvalidTokenType indicates an invalid token type identifier.
+	ErrInvalidTokenType = errors.New("token type is invalid")
+
+	// ValidTokenType is the validation function for a string containing a token type identifier.
+	ValidTokenType = func(s string) error {
+		switch s {
+		case TokenTypeAccessToken,
+			TokenTypeRefreshToken,
+			TokenTypeIdToken,
+			TokenTypeJwt,
+			TokenTypeSaml1,
+			TokenTypeSaml2:
+			return nil
+		default:
+			return ErrInvalidTokenType
+		}
+	}
+)
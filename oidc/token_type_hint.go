@@ -0,0 +1,23 @@
+package oidc
+
+import "errors"
+
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+var (
+	// ErrInvalidTokenTypeHint indicates an invalid token type hint value.
+	ErrInvalidTokenTypeHint = errors.New("token_type_hint is invalid")
+
+	// ValidTokenTypeHint is the validation function for a string containing a token type hint.
+	ValidTokenTypeHint = func(s string) error {
+		switch s {
+		case TokenTypeHintAccessToken, TokenTypeHintRefreshToken:
+			return nil
+		default:
+			return ErrInvalidTokenTypeHint
+		}
+	}
+)
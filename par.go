@@ -0,0 +1,79 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/imulab/coldcall"
+	"github.com/imulab/coldcall/body"
+	"net/url"
+)
+
+var (
+	ErrPushedAuthorizationNotSupported = errors.New("pushed authorization request endpoint is not available")
+)
+
+// PushedAuthorization is the response object at pushed authorization request endpoint.
+type PushedAuthorization struct {
+	// RequestURI is the reference to the pushed request, which is used in place of the
+	// request parameters at the authorization endpoint.
+	RequestURI string `json:"request_uri"`
+
+	// ExpiresIn is the lifetime of RequestURI in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+
+	// URL is the url to the authorization endpoint, referencing RequestURI, to which the
+	// End-User should be redirected.
+	URL string `json:"-"`
+}
+
+// PushAuthorizationRequest validates the AuthorizeRequest and pushes it to Tiga with client authentication, so
+// that large or sensitive parameters do not travel through the browser. Like AuthorizeURL, the generated secrets
+// are returned in the AuthorizeSession, which should be persisted to verify the authorization response.
+func (s *SDK) PushAuthorizationRequest(ctx context.Context, req *AuthorizeRequest) (*PushedAuthorization, *AuthorizeSession, error) {
+	discovery, err := s.getEndpoints(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(discovery.PushedAuthorizationRequestEndpoint) == 0 {
+		return nil, nil, ErrPushedAuthorizationNotSupported
+	}
+
+	params, session, err := s.authorizeParams(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.RequestObject || req.EncryptRequestObject {
+		if params, err = s.requestObjectParams(ctx, params, req.EncryptRequestObject); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	initial := map[string]string{}
+	for k := range params {
+		initial[k] = params.Get(k)
+	}
+
+	options, err := s.createTokenRequest(ctx, initial)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var successConstructor coldcall.Constructor = func() interface{} { return new(PushedAuthorization) }
+
+	result, err := s.postFormWithProof(ctx, discovery.PushedAuthorizationRequestEndpoint, options, body.JSONUnmarshal(successConstructor))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pushed := result.(*PushedAuthorization)
+	if pushed.URL, err = buildURL(discovery.AuthorizationEndpoint, url.Values{
+		"client_id":   {s.clientId},
+		"request_uri": {pushed.RequestURI},
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return pushed, session, nil
+}
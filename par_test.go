@@ -0,0 +1,59 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSDK_PushAuthorizationRequest(t *testing.T) {
+	details := `[{"type":"payment_initiation","instructedAmount":{"currency":"EUR","amount":"123.50"}}]`
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/par": func(rw http.ResponseWriter, r *http.Request) {
+			id, _, _ := r.BasicAuth()
+			assert.Equal(t, "client", id)
+			assert.Equal(t, "https://app.test/callback", r.PostFormValue("redirect_uri"))
+			assert.Equal(t, details, r.PostFormValue("authorization_details"))
+			assert.NotEmpty(t, r.PostFormValue("state"))
+			assert.NotEmpty(t, r.PostFormValue("code_challenge"))
+
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(rw).Encode(&tigasdk.PushedAuthorization{
+				RequestURI: "urn:ietf:params:oauth:request_uri:6esc_11ACC5bwc014ltc14eY22c",
+				ExpiresIn:  60,
+			})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	pushed, session, err := sdk.PushAuthorizationRequest(context.Background(), &tigasdk.AuthorizeRequest{
+		RedirectURI:          "https://app.test/callback",
+		AuthorizationDetails: []byte(details),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(60), pushed.ExpiresIn)
+	assert.NotEmpty(t, session.CodeVerifier)
+
+	u, err := url.Parse(pushed.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/oauth/authorize", u.Path)
+	assert.Equal(t, url.Values{
+		"client_id":   {"client"},
+		"request_uri": {pushed.RequestURI},
+	}, u.Query())
+}
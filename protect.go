@@ -37,6 +37,40 @@ type ProtectOpt struct {
 	// Leeway is the time skew tolerance
 	Leeway time.Duration
 
+	// Introspection controls when the access token is introspected at Tiga instead of being verified locally
+	// as JWT. By default, IntrospectNever is used. Introspection is only available with SDK.Protect.
+	Introspection IntrospectionMode
+
+	// IntrospectionCacheSize is the maximum number of introspection results to cache. When
+	// non-positive, at most 1000 results are cached.
+	IntrospectionCacheSize int
+
+	// IntrospectionCacheTTL is the maximum duration to cache an introspection result, which is
+	// further capped at the expiry of the token, or 1 second for results without expiry. When zero,
+	// results are cached for up to 30 seconds. When negative, results are not cached.
+	IntrospectionCacheTTL time.Duration
+
+	// DPoP controls whether access tokens bound to a DPoP key are accepted. By default, DPoPDisabled is used. Note
+	// that in DPoPDisabled mode, DPoP bound tokens presented as bearer tokens are not rejected.
+	DPoP DPoPMode
+
+	// DPoPProofMaxAge is the maximum age of a DPoP proof by its "iat" claim. When zero, 1 minute is used.
+	DPoPProofMaxAge time.Duration
+
+	// DPoPReplayCacheSize is the maximum number of DPoP proofs remembered to detect replays. Proofs are remembered
+	// until they are too old to be accepted, and new proofs are rejected when the capacity is reached. When
+	// non-positive, 10000 is used.
+	DPoPReplayCacheSize int
+
+	// DPoPBaseURL is the external base url of the resource server, used to compute the expected "htu" claim of
+	// DPoP proofs when the server is behind a reverse proxy. When empty, it is derived from the request.
+	DPoPBaseURL string
+
+	// CertificateBound requires the access token to be bound to the client certificate, that is, the "x5t#S256"
+	// confirmation of the token must match the certificate presented by the client in the mutual TLS connection.
+	// The server must be configured to request client certificates for r.TLS to contain the certificate.
+	CertificateBound bool
+
 	// RenderError is the function that is called in case of error. If not
 	// provided, the middleware just write 401 status.
 	RenderError func(http.ResponseWriter, *http.Request, error)
@@ -45,10 +79,62 @@ type ProtectOpt struct {
 // Protect returns a HTTP middleware to require access token issued by Tiga service in order to access the resource.
 // This function assumes the caller holds oidc.Discovery and the verifying jwx.KeySet.
 func Protect(discovery *oidc.Discovery, jwks *jwx.KeySet, opt *ProtectOpt) func(http.Handler) http.Handler {
+	return protect(
+		func(_ context.Context) (*oidc.Discovery, error) {
+			return discovery, nil
+		},
+		func(_ context.Context, token string, hint jwx.Algs, dest interface{}) error {
+			return jwx.Decode(token, jwks, nil, hint, dest)
+		},
+		nil,
+		opt,
+	)
+}
+
+// Protect returns a HTTP middleware to require access token issued by Tiga service in order to access the resource.
+// If the sdk was created with WithLazyBootstrap, the discovery document and the Tiga jwks are resolved upon the
+// first request. Failure to do so is rendered as error. Unlike the package level Protect function, tokens signed
+// by keys unknown to the sdk will trigger a refetch of the Tiga jwks.
+func (s *SDK) Protect(opt *ProtectOpt) func(http.Handler) http.Handler {
+	return protect(
+		s.getDiscovery,
+		func(ctx context.Context, token string, hint jwx.Algs, dest interface{}) error {
+			return s.decode(ctx, token, nil, hint, dest)
+		},
+		func(ctx context.Context, token string) (*IntrospectionResponse, error) {
+			return s.Introspect(ctx, token, oidc.TokenTypeHintAccessToken)
+		},
+		opt,
+	)
+}
+
+// protect implements the Protect middleware, with the oidc.Discovery resolved, and the access token decoded or
+// introspected by the provided functions on each request. When introspect is nil, introspection is disabled.
+func protect(
+	resolve func(ctx context.Context) (*oidc.Discovery, error),
+	decode func(ctx context.Context, token string, hint jwx.Algs, dest interface{}) error,
+	introspect func(ctx context.Context, token string) (*IntrospectionResponse, error),
+	opt *ProtectOpt,
+) func(http.Handler) http.Handler {
 	if opt == nil {
 		opt = &ProtectOpt{}
 	}
 
+	mode := opt.Introspection
+	if introspect == nil {
+		mode = IntrospectNever
+	}
+
+	var cache *introspectionCache
+	if mode != IntrospectNever {
+		cache = newIntrospectionCache(opt.IntrospectionCacheSize, opt.IntrospectionCacheTTL)
+	}
+
+	var dpop *dpopVerifier
+	if opt.DPoP != DPoPDisabled {
+		dpop = newDPoPVerifier(opt)
+	}
+
 	if opt.RenderError == nil {
 		opt.RenderError = func(rw http.ResponseWriter, r *http.Request, err error) {
 			rw.WriteHeader(http.StatusUnauthorized)
@@ -57,25 +143,69 @@ func Protect(discovery *oidc.Discovery, jwks *jwx.KeySet, opt *ProtectOpt) func(
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-			header := r.Header.Get("Authorization")
-			if len(header) == 0 || !strings.HasPrefix(header, AccessTokenType+" ") {
+			tokenType, rawToken, ok := parseAuthorization(r.Header.Get("Authorization"), opt.DPoP)
+			if !ok {
 				opt.RenderError(rw, r, ErrMalformedAuthHeader)
 				return
 			}
 
-			rawToken := strings.TrimPrefix(header, AccessTokenType+" ")
+			discovery, err := resolve(r.Context())
+			if err != nil {
+				opt.RenderError(rw, r, err)
+				return
+			}
 
 			var claims = new(AccessTokenClaims)
-			if err := jwx.Decode(
-				rawToken,
-				jwks, nil,
-				jwx.Algs{Sig: "?"}, // use '?' to trick IsNone, entirely depend on JWS header values
-				claims,
-			); err != nil {
-				opt.RenderError(rw, r, ErrInvalidAccessToken)
+			{
+				var err error
+				if mode != IntrospectAlways {
+					err = decode(
+						r.Context(),
+						rawToken,
+						jwx.Algs{Sig: "?"}, // use '?' to trick IsNone, entirely depend on JWS header values
+						claims,
+					)
+				}
+				if mode == IntrospectAlways || (err != nil && mode == IntrospectFallback) {
+					var resp *IntrospectionResponse
+					if resp, err = cache.introspect(r.Context(), rawToken, introspect); err == nil {
+						if resp.Active && resp.isAccessToken() {
+							claims = resp.toClaims(discovery.Issuer)
+						} else {
+							err = ErrInvalidAccessToken
+						}
+					}
+				}
+				if err != nil {
+					opt.RenderError(rw, r, ErrInvalidAccessToken)
+					return
+				}
+			}
+
+			// proofs are verified, hence remembered for replay detection, only for genuine access tokens
+			var jkt string
+			if tokenType == DPoPTokenType {
+				var err error
+				if jkt, err = dpop.verify(r, rawToken); err != nil {
+					opt.RenderError(rw, r, err)
+					return
+				}
+			}
+
+			if bound := claims.Cnf != nil && len(claims.Cnf.Jkt) > 0; (tokenType == DPoPTokenType && (!bound || claims.Cnf.Jkt != jkt)) ||
+				(tokenType == AccessTokenType && bound && opt.DPoP != DPoPDisabled) {
+				opt.RenderError(rw, r, ErrDPoPBindingMismatch)
 				return
 			}
 
+			if opt.CertificateBound {
+				if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 || claims.Cnf == nil ||
+					len(claims.Cnf.X5tS256) == 0 || claims.Cnf.X5tS256 != certificateThumbprint(r.TLS.PeerCertificates[0]) {
+					opt.RenderError(rw, r, ErrCertificateBindingMismatch)
+					return
+				}
+			}
+
 			var rules []jwx.Expect
 			{
 				rules = append(rules, jwx.ExpectIss(discovery.Issuer))
@@ -109,11 +239,12 @@ func Protect(discovery *oidc.Discovery, jwks *jwx.KeySet, opt *ProtectOpt) func(
 
 			ctx := context.WithValue(r.Context(), accessTokenContextKey{}, &AccessToken{
 				Value:          rawToken,
-				Type:           AccessTokenType,
+				Type:           tokenType,
 				ExpiresIn:      int64(claims.Expiry.Time().Sub(time.Now()) / time.Second),
 				ClientId:       claims.Client,
 				Scopes:         strings.Fields(claims.Scope),
 				UserInfoClaims: claims.UserInfo,
+				Actor:          claims.Act,
 			})
 
 			next.ServeHTTP(rw, r.WithContext(ctx))
@@ -121,9 +252,23 @@ func Protect(discovery *oidc.Discovery, jwks *jwx.KeySet, opt *ProtectOpt) func(
 	}
 }
 
-// Protect returns a HTTP middleware to require access token issued by Tiga service in order to access the resource.
-func (s *SDK) Protect(opt *ProtectOpt) func(http.Handler) http.Handler {
-	return Protect(s.discovery, s.tigaJwks, opt)
+// parseAuthorization parses the "Authorization" header into the token type and the access token. The "DPoP" scheme
+// is only accepted when DPoP is not disabled, and the "Bearer" scheme is not accepted when DPoP is required.
+func parseAuthorization(header string, mode DPoPMode) (string, string, bool) {
+	for _, tokenType := range []string{AccessTokenType, DPoPTokenType} {
+		if !strings.HasPrefix(header, tokenType+" ") {
+			continue
+		}
+
+		if (tokenType == AccessTokenType && mode == DPoPRequired) || (tokenType == DPoPTokenType && mode == DPoPDisabled) {
+			return "", "", false
+		}
+
+		rawToken := strings.TrimPrefix(header, tokenType+" ")
+		return tokenType, rawToken, len(rawToken) > 0
+	}
+
+	return "", "", false
 }
 
 type accessTokenContextKey struct{}
@@ -0,0 +1,146 @@
+package tigasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const requestObjectLifespan = 5 * time.Minute
+
+var (
+	ErrRequestObjectNotSupported   = errors.New("request parameter is not supported")
+	ErrUnsupportedRequestObjectAlg = errors.New("request object algorithm is not supported by tiga")
+)
+
+// requestObjectParams serializes all authorization request parameters into a request object, signed with the
+// client jwks, and optionally encrypted to the Tiga jwks. The returned parameters carry the request object in the
+// "request" parameter, along with the "client_id", "response_type" and "scope" parameters, which are required
+// outside the request object by OpenID Connect.
+func (s *SDK) requestObjectParams(ctx context.Context, params url.Values, encrypt bool) (url.Values, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sigAlg, err := s.requestObjectSigAlg(discovery)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := jwx.SkipKeySource
+	if encrypt {
+		tigaJwks, err := s.getTigaJwks(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		encryptAlg, encodeAlg, err := s.requestObjectEncryptionAlgs(discovery, tigaJwks)
+		if err != nil {
+			return nil, err
+		}
+		enc = jwx.EncryptionKeyByAlg(encryptAlg, encodeAlg, tigaJwks)
+	}
+
+	claims := map[string]interface{}{}
+	for k := range params {
+		v := params.Get(k)
+		switch k {
+		case "max_age":
+			if maxAge, err := strconv.ParseInt(v, 10, 64); err == nil {
+				claims[k] = maxAge
+				continue
+			}
+		case "claims", "authorization_details":
+			if json.Valid([]byte(v)) {
+				claims[k] = json.RawMessage(v)
+				continue
+			}
+		}
+		claims[k] = v
+	}
+
+	now := time.Now()
+	claims[jwx.ClaimIss] = s.clientId
+	claims[jwx.ClaimAud] = discovery.Issuer
+	claims[jwx.ClaimJti] = internal.RandomString(16)
+	claims[jwx.ClaimIat] = now.Unix()
+	claims[jwx.ClaimNbf] = now.Unix()
+	claims[jwx.ClaimExp] = now.Add(requestObjectLifespan).Unix()
+
+	request, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(sigAlg, s.clientJwks), enc, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return url.Values{
+		"client_id":     {s.clientId},
+		"response_type": {params.Get("response_type")},
+		"scope":         {params.Get("scope")},
+		"request":       {request},
+	}, nil
+}
+
+// requestObjectSigAlg picks the algorithm to sign the request object. It is the one set by WithRequestObjectAlgs,
+// or the first supported one that the client jwks has a key for. The algorithm set by WithRequestObjectAlgs is never
+// replaced: jwx.ErrInvalidSignatureAlg is returned if Tiga does not support it, and jwx.ErrNoSigningKey if the client
+// jwks has no key for it.
+func (s *SDK) requestObjectSigAlg(discovery *oidc.Discovery) (string, error) {
+	preferred, supported := s.requestObjectAlgs.Sig, discovery.RequestObjectSigningAlgValuesSupported
+	if len(preferred) == 0 {
+		return s.clientSigAlg("", supported)
+	}
+
+	if jwx.IsNone(preferred) || (len(supported) > 0 && !internal.NewSet(supported...).Contains(preferred)) {
+		return "", jwx.ErrInvalidSignatureAlg
+	}
+	if s.clientJwks == nil {
+		return "", jwx.ErrNoSigningKey
+	}
+	if _, ok := s.clientJwks.KeyForSigning(preferred); !ok {
+		return "", jwx.ErrNoSigningKey
+	}
+
+	return preferred, nil
+}
+
+// requestObjectEncryptionAlgs negotiates the algorithms to encrypt the request object. The key encryption algorithm
+// is the one set by WithRequestObjectAlgs, or the first supported one that the Tiga jwks has a key for. The content
+// encryption algorithm is the one set by WithRequestObjectAlgs, or A128CBC-HS256, which is the default of client
+// registration. Algorithms set by WithRequestObjectAlgs are never replaced: ErrUnsupportedRequestObjectAlg is
+// returned if Tiga does not support them, and jwx.ErrNoEncryptionKey if the Tiga jwks has no key for them.
+func (s *SDK) requestObjectEncryptionAlgs(discovery *oidc.Discovery, tigaJwks *jwx.KeySet) (string, string, error) {
+	supported := func(alg string, values []string) bool {
+		return len(values) == 0 || internal.NewSet(values...).Contains(alg)
+	}
+
+	encryptAlg := s.requestObjectAlgs.Encrypt
+	if jwx.IsNone(encryptAlg) {
+		for _, alg := range discovery.RequestObjectEncryptionAlgValuesSupported {
+			if _, ok := tigaJwks.KeyForEncryption(alg); ok && !jwx.IsNone(alg) {
+				encryptAlg = alg
+				break
+			}
+		}
+		if jwx.IsNone(encryptAlg) {
+			return "", "", jwx.ErrNoEncryptionKey
+		}
+	} else if !supported(encryptAlg, discovery.RequestObjectEncryptionAlgValuesSupported) {
+		return "", "", ErrUnsupportedRequestObjectAlg
+	} else if _, ok := tigaJwks.KeyForEncryption(encryptAlg); !ok {
+		return "", "", jwx.ErrNoEncryptionKey
+	}
+
+	encodeAlg := internal.Coalesce(s.requestObjectAlgs.Encode, jwx.A128CBC_HS256)
+	if jwx.IsNone(encodeAlg) || !supported(encodeAlg, discovery.RequestObjectEncryptionEncValuesSupported) {
+		return "", "", ErrUnsupportedRequestObjectAlg
+	}
+
+	return encryptAlg, encodeAlg, nil
+}
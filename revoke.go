@@ -0,0 +1,51 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/imulab/coldcall"
+)
+
+var (
+	ErrRevocationNotSupported = errors.New("revocation endpoint is not available")
+)
+
+// Revoke requests the revocation endpoint to revoke the access token or the refresh token, authenticating the
+// client with the configured token endpoint authentication method. The hint is optional and should be one of
+// oidc.TokenTypeHintAccessToken or oidc.TokenTypeHintRefreshToken. Revoking a refresh token may also revoke access
+// tokens issued with it.
+//
+// Revocation of an invalid or already revoked token is considered successful. Errors from the revocation endpoint
+// are returned as *ErrorResponse.
+func (s *SDK) Revoke(ctx context.Context, token string, hint string) error {
+	if len(hint) > 0 {
+		if err := oidc.ValidTokenTypeHint(hint); err != nil {
+			return err
+		}
+	}
+
+	discovery, err := s.getEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(discovery.RevocationEndpoint) == 0 {
+		return ErrRevocationNotSupported
+	}
+
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":       s.clientId,
+		"token":           token,
+		"token_type_hint": hint,
+	})
+	if err != nil {
+		return err
+	}
+
+	var successProducer coldcall.Producer = func(_ []byte) (interface{}, error) { return true, nil }
+
+	_, err = s.postForm(ctx, discovery.RevocationEndpoint, options, successProducer)
+
+	return err
+}
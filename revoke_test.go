@@ -0,0 +1,48 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestSDK_Revoke(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/revoke": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "client", r.PostFormValue("client_id"))
+			assert.Equal(t, "secret", r.PostFormValue("client_secret"))
+
+			switch r.PostFormValue("token") {
+			case "unsupported":
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(rw).Encode(&tigasdk.ErrorResponse{Code: "unsupported_token_type"})
+			default:
+				assert.Equal(t, oidc.TokenTypeHintRefreshToken, r.PostFormValue("token_type_hint"))
+				rw.WriteHeader(http.StatusOK)
+			}
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sdk.Revoke(context.Background(), "refresh-token", oidc.TokenTypeHintRefreshToken))
+
+	err = sdk.Revoke(context.Background(), "unsupported", "")
+	if assert.Error(t, err) {
+		assert.Equal(t, "unsupported_token_type", err.(*tigasdk.ErrorResponse).Code)
+		assert.Equal(t, http.StatusBadRequest, err.(*tigasdk.ErrorResponse).Status)
+	}
+
+	assert.Equal(t, oidc.ErrInvalidTokenTypeHint, sdk.Revoke(context.Background(), "token", "id_token"))
+}
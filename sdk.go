@@ -6,11 +6,9 @@ import (
 	"github.com/absurdlab/tiga-go-sdk/internal"
 	"github.com/absurdlab/tiga-go-sdk/jwx"
 	"github.com/absurdlab/tiga-go-sdk/oidc"
-	"github.com/imulab/coldcall"
-	"github.com/imulab/coldcall/body"
-	"github.com/imulab/coldcall/status"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -63,12 +61,58 @@ var (
 
 	// WithPrivateKeyJwt sets the client id, client jwks and the signature algorithm to use
 	// to sign the client_assertion parameter. The sdk will use private_key_jwt method when
-	// requesting token endpoint.
+	// requesting token endpoint. If the client jwks has no key for the algorithm, the first
+	// algorithm in TokenEndpointAuthSigningAlgValuesSupported that it has a key for is used.
 	WithPrivateKeyJwt = func(clientId string, clientJwks *jwx.KeySet, signingAlg string) Option {
 		return func(sdk *SDK) {
 			sdk.clientId = clientId
 			sdk.clientJwks = clientJwks
 			sdk.authSigAlg = signingAlg
+			sdk.authMethod = oidc.PrivateKeyJwt
+		}
+	}
+
+	// WithClientSecretJwt sets the client id, client secret and the HMAC signature algorithm (one of HS256, HS384
+	// or HS512) to sign the client_assertion parameter with a key derived from the client secret. The sdk will
+	// use client_secret_jwt method when requesting token endpoint. If signingAlg is empty, HS256 is used.
+	WithClientSecretJwt = func(clientId string, clientSecret string, signingAlg string) Option {
+		return func(sdk *SDK) {
+			sdk.clientId = clientId
+			sdk.clientSecret = clientSecret
+			sdk.authSigAlg = internal.Coalesce(signingAlg, jwx.HS256)
+			sdk.authMethod = oidc.ClientSecretJwt
+		}
+	}
+
+	// WithPublicClient sets the client id of a public client, which cannot keep a client secret, such as a
+	// command line tool or a native application. The sdk will use none as the token endpoint authentication
+	// method, and only send the client_id parameter. Public clients should use PKCE with the authorization
+	// code flow (see TokenByCodeWithPKCE).
+	WithPublicClient = func(clientId string) Option {
+		return func(sdk *SDK) {
+			sdk.clientId = clientId
+			sdk.authMethod = oidc.TokenAuthNone
+		}
+	}
+
+	// WithTLSClientAuth sets the client id and the PKI client certificate. The sdk will use tls_client_auth method
+	// when requesting token endpoint, presenting the certificate during TLS handshake, and will prefer the mutual TLS
+	// endpoint aliases advertised by Tiga. The http client set by WithHTTPClient is copied, not modified.
+	WithTLSClientAuth = func(clientId string, cert tls.Certificate) Option {
+		return func(sdk *SDK) {
+			sdk.clientId = clientId
+			sdk.clientCert = &cert
+			sdk.authMethod = oidc.TLSClientAuth
+		}
+	}
+
+	// WithSelfSignedTLSClientAuth is the same as WithTLSClientAuth, except that the certificate is self-signed and
+	// registered with Tiga, hence the sdk will use self_signed_tls_client_auth method.
+	WithSelfSignedTLSClientAuth = func(clientId string, cert tls.Certificate) Option {
+		return func(sdk *SDK) {
+			sdk.clientId = clientId
+			sdk.clientCert = &cert
+			sdk.authMethod = oidc.SelfSignedTLSClientAuth
 		}
 	}
 
@@ -79,6 +123,87 @@ var (
 		}
 	}
 
+	// WithLazyBootstrap defers fetching the discovery document and the Tiga jwks until they are
+	// first needed. The construction of the sdk will not fail even if Tiga is unreachable. Use
+	// SDK.Ready to probe whether bootstrap is possible, for instance, in a readiness check.
+	WithLazyBootstrap = func() Option {
+		return func(sdk *SDK) {
+			sdk.lazy = true
+		}
+	}
+
+	// WithBootstrapRetry sets the maximum number of attempts to fetch the discovery document and the
+	// Tiga jwks, and the initial backoff between attempts. The backoff doubles after each failed attempt.
+	// By default, 3 attempts are made with an initial backoff of 1 second.
+	WithBootstrapRetry = func(attempts int, backoff time.Duration) Option {
+		return func(sdk *SDK) {
+			sdk.bootstrapAttempts = attempts
+			sdk.bootstrapBackoff = backoff
+		}
+	}
+
+	// WithJwksRefresh sets the interval to periodically refetch the Tiga jwks in the background, and the cooldown
+	// period that limits how often the jwks can be refetched. Apart from the periodic refresh, the jwks is refetched
+	// when a token refers to a key not found in the jwks, which usually means Tiga has rotated its keys. If the
+	// jwks response carries "Cache-Control" or "Expires" headers that indicate a shorter lifetime than interval,
+	// the jwks is refetched sooner.
+	//
+	// By default, periodic refresh is disabled and the cooldown period is 30 seconds. Periodic refresh is stopped
+	// by SDK.Close.
+	WithJwksRefresh = func(interval time.Duration, cooldown time.Duration) Option {
+		return func(sdk *SDK) {
+			sdk.jwksRefreshInterval = interval
+			sdk.jwksRefetchCooldown = cooldown
+		}
+	}
+
+	// WithDiscoveryRefresh sets the interval to periodically refetch the discovery document in the background.
+	// Use SDK.OnDiscoveryChange to get notified when the discovery document changes. By default, periodic refresh
+	// is disabled. Periodic refresh is stopped by SDK.Close.
+	WithDiscoveryRefresh = func(interval time.Duration) Option {
+		return func(sdk *SDK) {
+			sdk.discoveryRefreshInterval = interval
+		}
+	}
+
+	// WithIdTokenEncryption sets the key encryption algorithm and the content encryption algorithm that the client
+	// has registered for id tokens. The sdk will decrypt id tokens with keys set by WithClientJwks, and reject id
+	// tokens that are not encrypted, or encrypted with other algorithms.
+	WithIdTokenEncryption = func(encryptAlg string, encodeAlg string) Option {
+		return func(sdk *SDK) {
+			sdk.idTokenAlgs = jwx.Algs{Encrypt: encryptAlg, Encode: encodeAlg}
+		}
+	}
+
+	// WithUserInfoEncryption sets the key encryption algorithm and the content encryption algorithm that the
+	// client has registered for userinfo responses. The sdk will decrypt userinfo responses with keys set by
+	// WithClientJwks, and reject userinfo responses that are not encrypted, or encrypted with other algorithms.
+	WithUserInfoEncryption = func(encryptAlg string, encodeAlg string) Option {
+		return func(sdk *SDK) {
+			sdk.userInfoAlgs = jwx.Algs{Encrypt: encryptAlg, Encode: encodeAlg}
+		}
+	}
+
+	// WithRequestObjectAlgs sets the algorithms the client has registered with Tiga for request objects. Empty
+	// algorithms are negotiated against the discovery document: the signature algorithm is the first supported one
+	// that the client jwks has a key for, the key encryption algorithm is the first supported one that the Tiga jwks
+	// has a key for, and the content encryption algorithm defaults to A128CBC-HS256. Algorithms set here are used as
+	// is, and request objects fail to build if Tiga does not support them or the jwks has no key for them.
+	WithRequestObjectAlgs = func(signingAlg string, encryptAlg string, encodeAlg string) Option {
+		return func(sdk *SDK) {
+			sdk.requestObjectAlgs = jwx.Algs{Sig: signingAlg, Encrypt: encryptAlg, Encode: encodeAlg}
+		}
+	}
+
+	// WithDPoP sets the private key, typically created by jwx.GenerateSignatureKey, to prove possession of with DPoP.
+	// The sdk will attach a DPoP proof to token requests, so that the issued tokens are bound to the key. Such
+	// tokens are presented by TokenSource.RoundTripper and SDK.UserInfo with a fresh DPoP proof on every request.
+	WithDPoP = func(key *jwx.Key) Option {
+		return func(sdk *SDK) {
+			sdk.dpop = newDPoPSigner(key)
+		}
+	}
+
 	// WithHTTPClient set the http client used by the sdk to make http request.
 	// By default, if nothing is set, the sdk uses a default http client with
 	// 10 second timeout and skips tls verification.
@@ -90,7 +215,21 @@ var (
 )
 
 // New creates a new sdk object to expose various features. A series of Option can be applied to customize its parameters.
+//
+// New panics if the discovery document or the Tiga jwks cannot be fetched. Use NewWithContext to handle the error
+// instead, or WithLazyBootstrap to defer the fetching until first use.
 func New(options ...Option) *SDK {
+	sdk, err := NewWithContext(context.Background(), options...)
+	if err != nil {
+		panic(err)
+	}
+	return sdk
+}
+
+// NewWithContext creates a new sdk object to expose various features. A series of Option can be applied to customize
+// its parameters. Unless WithLazyBootstrap is applied, the discovery document and the Tiga jwks are fetched before
+// return, and any failure to do so is returned as error.
+func NewWithContext(ctx context.Context, options ...Option) (*SDK, error) {
 	sdk := new(SDK)
 	for _, opt := range options {
 		opt(sdk)
@@ -99,64 +238,90 @@ func New(options ...Option) *SDK {
 	if sdk.httpClient == nil {
 		sdk.httpClient = DefaultHTTPClient
 	}
+	if sdk.clientCert != nil {
+		httpClient, err := withClientCertificate(sdk.httpClient, *sdk.clientCert)
+		if err != nil {
+			return nil, err
+		}
+		sdk.httpClient = httpClient
+	}
+	if sdk.bootstrapAttempts <= 0 {
+		sdk.bootstrapAttempts = defaultBootstrapAttempts
+	}
+	if sdk.bootstrapBackoff <= 0 {
+		sdk.bootstrapBackoff = defaultBootstrapBackoff
+	}
+
+	if sdk.jwksRefetchCooldown <= 0 {
+		sdk.jwksRefetchCooldown = defaultJwksRefetchCooldown
+	}
 
 	sdk.serviceBaseURL = internal.Coalesce(sdk.serviceBaseURL, DefaultServiceBaseURL)
+	sdk.tigaJwks = &remoteKeySet{
+		fetch:    sdk.fetchTigaJwks,
+		interval: sdk.jwksRefreshInterval,
+		cooldown: sdk.jwksRefetchCooldown,
+	}
+	sdk.stop = make(chan struct{})
 
-	sdk.mustGetDiscovery()
-	sdk.mustGetTigaJwks()
+	if !sdk.lazy {
+		if err := sdk.bootstrap(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	return sdk
+	if sdk.jwksRefreshInterval > 0 {
+		go sdk.tigaJwks.run(sdk.stop)
+	}
+	if sdk.discoveryRefreshInterval > 0 {
+		go sdk.runDiscoveryRefresh(sdk.discoveryRefreshInterval, sdk.stop)
+	}
+
+	return sdk, nil
 }
 
 // SDK is the entrypoint of the kit.
 type SDK struct {
-	clientId       string
-	clientSecret   string
-	clientJwks     *jwx.KeySet
-	authMethod     string
-	authSigAlg     string
-	serviceBaseURL string
-	discovery      *oidc.Discovery
-	tigaJwks       *jwx.KeySet
-	httpClient     *http.Client
-}
+	clientId          string
+	clientSecret      string
+	clientJwks        *jwx.KeySet
+	clientCert        *tls.Certificate
+	authMethod        string
+	authSigAlg        string
+	idTokenAlgs       jwx.Algs
+	userInfoAlgs      jwx.Algs
+	requestObjectAlgs jwx.Algs
+	serviceBaseURL    string
+	httpClient        *http.Client
 
-func (s *SDK) mustGetDiscovery() {
-	req, err := coldcall.Get(context.Background(), s.serviceBaseURL+"/.well-known/openid-configuration")
-	if err != nil {
-		panic(err)
-	}
+	lazy              bool
+	bootstrapAttempts int
+	bootstrapBackoff  time.Duration
+	bootstrapMu       sync.RWMutex
+	bootstrapFlight   internal.Flight
+	discovery         *oidc.Discovery
 
-	var newDiscovery coldcall.Constructor = func() interface{} {
-		return new(oidc.Discovery)
-	}
-
-	d, _, err := coldcall.Response(s.httpClient.Do(req)).
-		Expect(status.Is200, body.JSONUnmarshal(newDiscovery)).
-		Read()
-	if err != nil {
-		panic(err)
-	}
+	discoveryRefreshInterval time.Duration
+	listenerMu               sync.Mutex
+	discoveryListeners       []func(old, new *oidc.Discovery)
 
-	s.discovery = d.(*oidc.Discovery)
-}
+	jwksRefreshInterval time.Duration
+	jwksRefetchCooldown time.Duration
+	tigaJwks            *remoteKeySet
 
-func (s *SDK) mustGetTigaJwks() {
-	req, err := coldcall.Get(context.Background(), s.serviceBaseURL+"/.well-known/jwks.json")
-	if err != nil {
-		panic(err)
-	}
+	tokenCache tokenCache
 
-	var newJwks coldcall.Constructor = func() interface{} {
-		return jwx.NewKeySet()
-	}
+	dpop *dpopSigner
 
-	set, _, err := coldcall.Response(s.httpClient.Do(req)).
-		Expect(status.Is200, body.JSONUnmarshal(newJwks)).
-		Read()
-	if err != nil {
-		panic(err)
-	}
+	stop      chan struct{}
+	closeOnce sync.Once
+}
 
-	s.tigaJwks = set.(*jwx.KeySet)
+// Close stops the background activities of the sdk, such as periodic refreshes. The sdk remains usable
+// after Close, but will no longer refresh in the background.
+func (s *SDK) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	return nil
 }
@@ -0,0 +1,163 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewWithContext(t *testing.T) {
+	var up int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&up) == 0 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(rw).Encode(&oidc.Discovery{Issuer: "https://tiga.test"})
+		case "/.well-known/jwks.json":
+			_ = json.NewEncoder(rw).Encode(jwx.NewKeySet(jwx.GenerateSignatureKey("k1", jwx.ES256, 0)).ToPublic())
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("eager bootstrap reports error", func(t *testing.T) {
+		sdk, err := tigasdk.NewWithContext(context.Background(),
+			tigasdk.WithServiceBaseURL(srv.URL),
+			tigasdk.WithBootstrapRetry(2, time.Millisecond),
+		)
+		assert.Error(t, err)
+		assert.Nil(t, sdk)
+	})
+
+	t.Run("waiting callers respect their context", func(t *testing.T) {
+		sdk, err := tigasdk.NewWithContext(context.Background(),
+			tigasdk.WithServiceBaseURL(srv.URL),
+			tigasdk.WithBootstrapRetry(3, 500*time.Millisecond),
+			tigasdk.WithLazyBootstrap(),
+		)
+		assert.NoError(t, err)
+
+		go func() { _ = sdk.Ready(context.Background()) }()
+		time.Sleep(50 * time.Millisecond)
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, sdk.Ready(ctx))
+		assert.Nil(t, sdk.Discovery())
+		assert.True(t, time.Since(start) < 300*time.Millisecond)
+	})
+
+	t.Run("lazy bootstrap", func(t *testing.T) {
+		sdk, err := tigasdk.NewWithContext(context.Background(),
+			tigasdk.WithServiceBaseURL(srv.URL),
+			tigasdk.WithBootstrapRetry(1, time.Millisecond),
+			tigasdk.WithLazyBootstrap(),
+		)
+		assert.NoError(t, err)
+		assert.Error(t, sdk.Ready(context.Background()))
+		assert.Nil(t, sdk.Discovery())
+
+		atomic.StoreInt32(&up, 1)
+
+		assert.NoError(t, sdk.Ready(context.Background()))
+		assert.Equal(t, "https://tiga.test", sdk.Discovery().Issuer)
+	})
+}
+
+func TestSDK_OnDiscoveryChange(t *testing.T) {
+	var lifespan int64 = 3600
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(rw).Encode(&oidc.Discovery{
+				Issuer:              "https://tiga.test",
+				AccessTokenLifespan: atomic.LoadInt64(&lifespan),
+			})
+		case "/.well-known/jwks.json":
+			_ = json.NewEncoder(rw).Encode(jwx.NewKeySet())
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithDiscoveryRefresh(10*time.Millisecond),
+	)
+	assert.NoError(t, err)
+	defer sdk.Close()
+
+	changed := make(chan [2]int64, 1)
+	sdk.OnDiscoveryChange(func(old, new *oidc.Discovery) {
+		changed <- [2]int64{old.AccessTokenLifespan, new.AccessTokenLifespan}
+	})
+
+	atomic.StoreInt64(&lifespan, 7200)
+
+	select {
+	case c := <-changed:
+		assert.Equal(t, [2]int64{3600, 7200}, c)
+		assert.Equal(t, int64(7200), sdk.Discovery().AccessTokenLifespan)
+	case <-time.After(time.Second):
+		t.Fatal("discovery change not observed")
+	}
+}
+
+// newTigaServer starts a fake Tiga server which serves the discovery document, with endpoints pointing
+// to the server itself, the public portion of jwks, and the given handlers keyed by path.
+func newTigaServer(jwks *jwx.KeySet, handlers map[string]http.HandlerFunc) *httptest.Server {
+	var (
+		srv                       *httptest.Server
+		requestParameterSupported = true
+	)
+	srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(rw).Encode(&oidc.Discovery{
+				Issuer:                                     srv.URL,
+				AuthorizationEndpoint:                      srv.URL + "/oauth/authorize",
+				TokenEndpoint:                              srv.URL + "/oauth/token",
+				UserInfoEndpoint:                           srv.URL + "/userinfo",
+				IntrospectionEndpoint:                      srv.URL + "/oauth/introspect",
+				RevocationEndpoint:                         srv.URL + "/oauth/revoke",
+				DeviceAuthorizationEndpoint:                srv.URL + "/oauth/device_authorization",
+				PushedAuthorizationRequestEndpoint:         srv.URL + "/oauth/par",
+				EndSessionEndpoint:                         srv.URL + "/oauth/logout",
+				LoginEndpoint:                              srv.URL + "/interaction/login",
+				SelectAccountEndpoint:                      srv.URL + "/interaction/select_account",
+				ConsentEndpoint:                            srv.URL + "/interaction/consent",
+				AccessTokenLifespan:                        3600,
+				TokenEndpointAuthSigningAlgValuesSupported: []string{jwx.RS256, jwx.ES256},
+				RequestParameterSupported:                  &requestParameterSupported,
+				RequestObjectSigningAlgValuesSupported:     []string{jwx.RS256, jwx.ES256},
+				RequestObjectEncryptionAlgValuesSupported:  []string{jwx.RSA_OAEP_256, jwx.ECDH_ES_A128KW},
+				RequestObjectEncryptionEncValuesSupported:  []string{jwx.A128CBC_HS256},
+			})
+		case "/.well-known/jwks.json":
+			_ = json.NewEncoder(rw).Encode(jwks.ToPublic())
+		default:
+			if h, ok := handlers[r.URL.Path]; ok {
+				h(rw, r)
+				return
+			}
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv
+}
@@ -0,0 +1,434 @@
+package tigasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSessionCookieName      = "tiga_session"
+	defaultSessionIdleTimeout     = time.Hour
+	defaultSessionMaxLifetime     = 24 * time.Hour
+	defaultSessionCookieMaxChunks = 3
+	defaultSessionStoreSize       = 10000
+	sessionCookieChunkSize        = 3800
+	sessionEncryptionEnc          = jwx.A256GCM
+	sessionKeySize                = 32
+)
+
+var (
+	ErrInvalidSessionKey = errors.New("session key must be a 256 bit symmetric key with A256GCMKW or dir algorithm")
+	ErrNoSession         = errors.New("session is not found")
+	ErrInvalidSession    = errors.New("session is invalid")
+	ErrSessionExpired    = errors.New("session has expired")
+	ErrSessionTooLarge   = errors.New("session is too large to fit in cookies")
+)
+
+// Session is the End-User's login session at a relying party.
+type Session struct {
+	Subject      string                 `json:"sub,omitempty"`
+	AccessToken  string                 `json:"access_token,omitempty"`
+	TokenType    string                 `json:"token_type,omitempty"`
+	RefreshToken string                 `json:"refresh_token,omitempty"`
+	IdToken      string                 `json:"id_token,omitempty"`
+	Expiry       int64                  `json:"expiry,omitempty"`
+	Claims       map[string]interface{} `json:"claims,omitempty"`
+	CreatedAt    int64                  `json:"created_at"`
+
+	// CSRFToken is the token which unsafe requests must echo, so that it is tied to the session, see BFF.
+	CSRFToken string `json:"csrf,omitempty"`
+
+	// ref is the id of the session in the SessionStore, if it was stored server-side.
+	ref string
+}
+
+// NewSession creates a Session from the tokens and the verified id token claims, typically those in CallbackResult.
+// The idToken may be nil.
+func NewSession(tokens *TokenResponse, idToken *IdTokenClaims) *Session {
+	session := &Session{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokens.TokenType,
+		RefreshToken: tokens.RefreshToken,
+		IdToken:      tokens.IdToken,
+		CreatedAt:    time.Now().Unix(),
+	}
+	if tokens.ExpiresIn != nil {
+		session.Expiry = time.Now().Add(time.Duration(*tokens.ExpiresIn) * time.Second).Unix()
+	}
+	if idToken != nil {
+		session.Subject = idToken.Subject
+		session.Claims = idToken.Raw
+	}
+	return session
+}
+
+// copy returns a copy of the session which does not share the claims map with it.
+func (s *Session) copy() *Session {
+	cp := *s
+	if s.Claims != nil {
+		cp.Claims = make(map[string]interface{}, len(s.Claims))
+		for k, v := range s.Claims {
+			cp.Claims[k] = v
+		}
+	}
+	return &cp
+}
+
+// SessionStore persists sessions server-side, when they are too large to fit in cookies. The cookie then only
+// carries the encrypted id of the session.
+type SessionStore interface {
+	// Save persists the session by id until expiry.
+	Save(ctx context.Context, id string, session *Session, expiry time.Time) error
+
+	// Load loads the session by id. When the session does not exist or has expired, ErrNoSession is returned.
+	Load(ctx context.Context, id string) (*Session, error)
+
+	// Delete removes the session by id, if it exists.
+	Delete(ctx context.Context, id string) error
+}
+
+// NewMemorySessionStore creates a SessionStore which keeps at most size sessions in memory. When non-positive,
+// size defaults to 10000. It is only suitable for single instance deployments.
+func NewMemorySessionStore(size int) SessionStore {
+	if size <= 0 {
+		size = defaultSessionStoreSize
+	}
+	return &memorySessionStore{cache: internal.NewCache(size)}
+}
+
+type memorySessionStore struct {
+	cache *internal.Cache
+}
+
+// Save and Load work on copies of the session, so that concurrent requests of the same session do not share it.
+func (m *memorySessionStore) Save(_ context.Context, id string, session *Session, expiry time.Time) error {
+	m.cache.Put(id, session.copy(), expiry)
+	return nil
+}
+
+func (m *memorySessionStore) Load(_ context.Context, id string) (*Session, error) {
+	v, ok := m.cache.Get(id)
+	if !ok {
+		return nil, ErrNoSession
+	}
+	return v.(*Session).copy(), nil
+}
+
+func (m *memorySessionStore) Delete(_ context.Context, id string) error {
+	m.cache.Take(id)
+	return nil
+}
+
+// SessionOpt is the options for NewSessionManager.
+type SessionOpt struct {
+	// Keys is the set of symmetric keys to decrypt session cookies. To rotate keys, add the new key, make it
+	// primary, and keep the old keys until sessions encrypted with them have expired.
+	Keys *jwx.KeySet
+
+	// PrimaryKeyId is the kid of the key in Keys to encrypt session cookies. The key must be a 256 bit symmetric
+	// key with A256GCMKW or dir algorithm, see jwx.NewSymmetricKey.
+	PrimaryKeyId string
+
+	// CookieName is the name of the session cookie. Additional chunks are named with ".1", ".2", etc. appended.
+	// When empty, "tiga_session" is used.
+	CookieName string
+
+	// CookiePath is the path attribute of the session cookies. When empty, "/" is used.
+	CookiePath string
+
+	// CookieDomain is the optional domain attribute of the session cookies.
+	CookieDomain string
+
+	// SameSite is the same site attribute of the session cookies. When unset, http.SameSiteLaxMode is used.
+	SameSite http.SameSite
+
+	// Insecure allows session cookies to be sent over plain http. It is intended for local development only.
+	Insecure bool
+
+	// IdleTimeout is the duration after which the session expires without activity. The expiry slides forward
+	// when the session is loaded after half of the duration has passed. When zero, 1 hour is used.
+	IdleTimeout time.Duration
+
+	// MaxLifetime is the duration since the creation of the session after which the session expires regardless
+	// of activity. When zero, 24 hours is used.
+	MaxLifetime time.Duration
+
+	// MaxCookieChunks is the maximum number of cookies to split the session into. When zero, 3 is used.
+	MaxCookieChunks int
+
+	// Store is the optional SessionStore for sessions which exceed MaxCookieChunks. When nil, ErrSessionTooLarge
+	// is returned for such sessions.
+	Store SessionStore
+}
+
+// SessionManager stores sessions in cookies encrypted as JWE.
+type SessionManager struct {
+	opt     SessionOpt
+	primary *jwx.Key
+}
+
+// sessionEnvelope is the encrypted payload of the session cookie.
+type sessionEnvelope struct {
+	Session  *Session `json:"s,omitempty"`
+	Ref      string   `json:"ref,omitempty"`
+	IssuedAt int64    `json:"iat"`
+	Expiry   int64    `json:"exp"`
+}
+
+// NewSessionManager creates a SessionManager with the options. ErrInvalidSessionKey is returned if the primary key
+// is absent or unsuitable.
+func NewSessionManager(opt *SessionOpt) (*SessionManager, error) {
+	if opt == nil || opt.Keys == nil {
+		return nil, ErrInvalidSessionKey
+	}
+
+	primary, ok := opt.Keys.KeyById(opt.PrimaryKeyId)
+	if !ok || !primary.IsSymmetric() || len(primary.Raw().([]byte)) != sessionKeySize {
+		return nil, ErrInvalidSessionKey
+	}
+	if alg := primary.Alg(); alg != jwx.A256GCMKW && alg != jwx.DIRECT {
+		return nil, ErrInvalidSessionKey
+	}
+
+	m := &SessionManager{opt: *opt, primary: primary}
+	if len(m.opt.CookieName) == 0 {
+		m.opt.CookieName = defaultSessionCookieName
+	}
+	if len(m.opt.CookiePath) == 0 {
+		m.opt.CookiePath = "/"
+	}
+	if m.opt.SameSite == 0 {
+		m.opt.SameSite = http.SameSiteLaxMode
+	}
+	if m.opt.IdleTimeout <= 0 {
+		m.opt.IdleTimeout = defaultSessionIdleTimeout
+	}
+	if m.opt.MaxLifetime <= 0 {
+		m.opt.MaxLifetime = defaultSessionMaxLifetime
+	}
+	if m.opt.MaxCookieChunks <= 0 {
+		m.opt.MaxCookieChunks = defaultSessionCookieMaxChunks
+	}
+
+	return m, nil
+}
+
+// Load reads the session from the request cookies. ErrNoSession is returned when there is no session cookie,
+// ErrSessionExpired when the session has expired, and ErrInvalidSession when the cookie cannot be decrypted.
+//
+// When half of the idle timeout has passed, or the cookie was encrypted with a key other than the primary key,
+// the session is saved again to slide its expiry and re-encrypt it.
+func (m *SessionManager) Load(rw http.ResponseWriter, r *http.Request) (*Session, error) {
+	token := m.readCookies(r)
+	if len(token) == 0 {
+		return nil, ErrNoSession
+	}
+
+	env, hd, err := m.decrypt(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Unix() >= env.Expiry {
+		m.clearCookies(rw, r)
+		return nil, ErrSessionExpired
+	}
+
+	session := env.Session
+	if len(env.Ref) > 0 {
+		if m.opt.Store == nil {
+			return nil, ErrInvalidSession
+		}
+		if session, err = m.opt.Store.Load(r.Context(), env.Ref); err != nil {
+			return nil, err
+		}
+		session.ref = env.Ref
+	}
+	if session == nil {
+		return nil, ErrInvalidSession
+	}
+
+	if now.Sub(time.Unix(env.IssuedAt, 0)) > m.opt.IdleTimeout/2 || hd.KeyId != m.primary.Id() {
+		if err := m.Save(rw, r, session); err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// Save encrypts the session with the primary key and writes it in cookies, split into chunks when necessary. When
+// it needs more than MaxCookieChunks cookies, the session is saved in the SessionStore instead.
+func (m *SessionManager) Save(rw http.ResponseWriter, r *http.Request, session *Session) error {
+	now := time.Now()
+	if session.CreatedAt == 0 {
+		session.CreatedAt = now.Unix()
+	}
+
+	expiry := now.Add(m.opt.IdleTimeout)
+	if limit := time.Unix(session.CreatedAt, 0).Add(m.opt.MaxLifetime); limit.Before(expiry) {
+		expiry = limit
+	}
+	if !expiry.After(now) {
+		m.clearCookies(rw, r)
+		return ErrSessionExpired
+	}
+
+	env := &sessionEnvelope{Session: session, IssuedAt: now.Unix(), Expiry: expiry.Unix()}
+
+	token, err := m.encrypt(env)
+	if err != nil {
+		return err
+	}
+
+	if chunks(token, sessionCookieChunkSize) > m.opt.MaxCookieChunks {
+		if m.opt.Store == nil {
+			return ErrSessionTooLarge
+		}
+		if len(session.ref) == 0 {
+			session.ref = internal.RandomString(32)
+		}
+		if err := m.opt.Store.Save(r.Context(), session.ref, session, expiry); err != nil {
+			return err
+		}
+		if token, err = m.encrypt(&sessionEnvelope{Ref: session.ref, IssuedAt: env.IssuedAt, Expiry: env.Expiry}); err != nil {
+			return err
+		}
+	} else if len(session.ref) > 0 {
+		if err := m.opt.Store.Delete(r.Context(), session.ref); err != nil {
+			return err
+		}
+		session.ref = ""
+	}
+
+	m.writeCookies(rw, r, token, expiry)
+	return nil
+}
+
+// Clear removes the session cookies, and the session in the SessionStore, if any.
+func (m *SessionManager) Clear(rw http.ResponseWriter, r *http.Request) error {
+	defer m.clearCookies(rw, r)
+
+	token := m.readCookies(r)
+	if len(token) == 0 || m.opt.Store == nil {
+		return nil
+	}
+
+	env, _, err := m.decrypt(token)
+	if err != nil || len(env.Ref) == 0 {
+		return nil
+	}
+
+	return m.opt.Store.Delete(r.Context(), env.Ref)
+}
+
+// decrypt decrypts the session cookie into the envelope. As the header of the cookie is controlled by the client,
+// it is checked before any decryption attempt: only the key algorithms of session keys and A256GCM are accepted,
+// so that expensive algorithms, such as PBES2 with a large iteration count, are never run. ErrInvalidSession is
+// returned for cookies failing the checks or the decryption.
+func (m *SessionManager) decrypt(token string) (*sessionEnvelope, *jwx.Header, error) {
+	hd, err := jwx.ReadHeader(token)
+	if err != nil || !hd.IsEncrypted() || hd.Enc != sessionEncryptionEnc {
+		return nil, nil, ErrInvalidSession
+	}
+	if hd.Alg != jwx.A256GCMKW && hd.Alg != jwx.DIRECT {
+		return nil, nil, ErrInvalidSession
+	}
+	if key, ok := m.opt.Keys.KeyById(hd.KeyId); !ok || key.Alg() != hd.Alg || !key.IsSymmetric() {
+		return nil, nil, ErrInvalidSession
+	}
+
+	plain, err := jwx.Decrypt(token, m.opt.Keys)
+	if err != nil {
+		return nil, nil, ErrInvalidSession
+	}
+
+	env := new(sessionEnvelope)
+	if err := json.Unmarshal(plain, env); err != nil {
+		return nil, nil, ErrInvalidSession
+	}
+
+	return env, hd, nil
+}
+
+func (m *SessionManager) encrypt(env *sessionEnvelope) (string, error) {
+	enc := func() (*jwx.Key, jwx.Algs, bool) {
+		return m.primary, jwx.Algs{Encrypt: m.primary.Alg(), Encode: sessionEncryptionEnc}, true
+	}
+	return jwx.EncodeToString(jwx.SkipKeySource, enc, env)
+}
+
+func (m *SessionManager) chunkName(i int) string {
+	if i == 0 {
+		return m.opt.CookieName
+	}
+	return m.opt.CookieName + "." + strconv.Itoa(i)
+}
+
+// readCookies joins the chunks of the session cookie.
+func (m *SessionManager) readCookies(r *http.Request) string {
+	var sb strings.Builder
+	for i := 0; ; i++ {
+		c, err := r.Cookie(m.chunkName(i))
+		if err != nil {
+			break
+		}
+		sb.WriteString(c.Value)
+	}
+	return sb.String()
+}
+
+// writeCookies splits the token into chunks and writes them as cookies, expiring stale chunks of the request.
+func (m *SessionManager) writeCookies(rw http.ResponseWriter, r *http.Request, token string, expiry time.Time) {
+	n := chunks(token, sessionCookieChunkSize)
+	for i := 0; i < n; i++ {
+		end := (i + 1) * sessionCookieChunkSize
+		if end > len(token) {
+			end = len(token)
+		}
+		http.SetCookie(rw, m.cookie(m.chunkName(i), token[i*sessionCookieChunkSize:end], int(time.Until(expiry).Seconds())))
+	}
+
+	for i := n; ; i++ {
+		if _, err := r.Cookie(m.chunkName(i)); err != nil {
+			break
+		}
+		http.SetCookie(rw, m.cookie(m.chunkName(i), "", -1))
+	}
+}
+
+// clearCookies expires all chunks of the session cookie in the request.
+func (m *SessionManager) clearCookies(rw http.ResponseWriter, r *http.Request) {
+	for i := 0; ; i++ {
+		if _, err := r.Cookie(m.chunkName(i)); err != nil {
+			break
+		}
+		http.SetCookie(rw, m.cookie(m.chunkName(i), "", -1))
+	}
+}
+
+func (m *SessionManager) cookie(name string, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     m.opt.CookiePath,
+		Domain:   m.opt.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   !m.opt.Insecure,
+		HttpOnly: true,
+		SameSite: m.opt.SameSite,
+	}
+}
+
+// chunks returns the number of chunks of size to split the value into.
+func chunks(value string, size int) int {
+	return (len(value) + size - 1) / size
+}
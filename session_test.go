@@ -0,0 +1,196 @@
+package tigasdk_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionManager(t *testing.T) {
+	var (
+		oldKey = jwx.NewSymmetricKey("old", jwx.DIRECT, jwx.UseEnc, bytes.Repeat([]byte{1}, 32))
+		newKey = jwx.NewSymmetricKey("new", jwx.A256GCMKW, jwx.UseEnc, bytes.Repeat([]byte{2}, 32))
+	)
+
+	newManager := func(t *testing.T, primary string, store tigasdk.SessionStore) *tigasdk.SessionManager {
+		m, err := tigasdk.NewSessionManager(&tigasdk.SessionOpt{
+			Keys:         jwx.NewKeySet(oldKey, newKey),
+			PrimaryKeyId: primary,
+			Store:        store,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return m
+	}
+
+	// roundTrip saves the session and returns a request carrying the resulting cookies.
+	roundTrip := func(t *testing.T, m *tigasdk.SessionManager, session *tigasdk.Session) (*http.Request, []*http.Cookie) {
+		rw := httptest.NewRecorder()
+		assert.NoError(t, m.Save(rw, httptest.NewRequest(http.MethodGet, "/", nil), session))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		cookies := rw.Result().Cookies()
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		return r, cookies
+	}
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := tigasdk.NewSessionManager(&tigasdk.SessionOpt{
+			Keys:         jwx.NewKeySet(jwx.NewSymmetricKey("short", jwx.A256GCMKW, jwx.UseEnc, []byte("short"))),
+			PrimaryKeyId: "short",
+		})
+		assert.Equal(t, tigasdk.ErrInvalidSessionKey, err)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		m := newManager(t, "new", nil)
+
+		r, cookies := roundTrip(t, m, &tigasdk.Session{Subject: "user", AccessToken: "token"})
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, "tiga_session", cookies[0].Name)
+			assert.True(t, cookies[0].HttpOnly)
+			assert.True(t, cookies[0].Secure)
+			assert.NotContains(t, cookies[0].Value, "token")
+		}
+
+		session, err := m.Load(httptest.NewRecorder(), r)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "user", session.Subject)
+			assert.Equal(t, "token", session.AccessToken)
+		}
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		m := newManager(t, "new", nil)
+
+		r, cookies := roundTrip(t, m, &tigasdk.Session{AccessToken: strings.Repeat("a", 4000)})
+		assert.Len(t, cookies, 2)
+
+		session, err := m.Load(httptest.NewRecorder(), r)
+		if assert.NoError(t, err) {
+			assert.Len(t, session.AccessToken, 4000)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		m := newManager(t, "new", nil)
+		err := m.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil),
+			&tigasdk.Session{AccessToken: strings.Repeat("a", 20000)})
+		assert.Equal(t, tigasdk.ErrSessionTooLarge, err)
+	})
+
+	t.Run("server-side store", func(t *testing.T) {
+		m := newManager(t, "new", tigasdk.NewMemorySessionStore(0))
+
+		saved := &tigasdk.Session{AccessToken: strings.Repeat("a", 20000), Claims: map[string]interface{}{"email": "user@tiga.test"}}
+		r, cookies := roundTrip(t, m, saved)
+		assert.Len(t, cookies, 1)
+		saved.Claims["email"] = "saved@tiga.test"
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				session, err := m.Load(httptest.NewRecorder(), r)
+				if assert.NoError(t, err) {
+					session.AccessToken = "altered"
+					session.Claims["email"] = "altered@tiga.test"
+				}
+			}()
+		}
+		wg.Wait()
+
+		session, err := m.Load(httptest.NewRecorder(), r)
+		if assert.NoError(t, err) {
+			assert.Len(t, session.AccessToken, 20000)
+			assert.Equal(t, "user@tiga.test", session.Claims["email"])
+		}
+
+		assert.NoError(t, m.Clear(httptest.NewRecorder(), r))
+		_, err = m.Load(httptest.NewRecorder(), r)
+		assert.Equal(t, tigasdk.ErrNoSession, err)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		r, _ := roundTrip(t, newManager(t, "old", nil), &tigasdk.Session{Subject: "user"})
+
+		rw := httptest.NewRecorder()
+		session, err := newManager(t, "new", nil).Load(rw, r)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "user", session.Subject)
+		}
+
+		if cookies := rw.Result().Cookies(); assert.Len(t, cookies, 1) {
+			header, err := jwx.ReadHeader(cookies[0].Value)
+			assert.NoError(t, err)
+			assert.Equal(t, "new", header.KeyId)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		m := newManager(t, "new", nil)
+		err := m.Save(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil),
+			&tigasdk.Session{CreatedAt: time.Now().Add(-48 * time.Hour).Unix()})
+		assert.Equal(t, tigasdk.ErrSessionExpired, err)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "tiga_session", Value: "garbage"})
+		_, err := newManager(t, "new", nil).Load(httptest.NewRecorder(), r)
+		assert.Equal(t, tigasdk.ErrInvalidSession, err)
+	})
+
+	t.Run("unexpected algorithms", func(t *testing.T) {
+		envelope := map[string]interface{}{
+			"s":   map[string]interface{}{"sub": "user"},
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		encrypt := func(key *jwx.Key, enc string) string {
+			token, err := jwx.EncodeToString(nil, jwx.EncryptionKeyByAlg(key.Alg(), enc, jwx.NewKeySet(key)), envelope)
+			assert.NoError(t, err)
+			return token
+		}
+		// forge builds a cookie with the header, which would take minutes to decrypt with PBES2
+		forge := func(header string) string {
+			return base64.RawURLEncoding.EncodeToString([]byte(header)) + ".AAAA.AAAAAAAAAAAAAAAA.AAAA.AAAAAAAAAAAAAAAAAAAAAA"
+		}
+		const p2c = `"p2c":2147483647,"p2s":"c2FsdHNhbHRzYWx0c2FsdA"`
+
+		for _, c := range []struct {
+			name   string
+			cookie string
+		}{
+			{name: "pbes2", cookie: forge(`{"alg":"PBES2-HS256+A128KW","enc":"A256GCM","kid":"new",` + p2c + `}`)},
+			{name: "pbes2 without kid", cookie: forge(`{"alg":"PBES2-HS256+A128KW","enc":"A256GCM",` + p2c + `}`)},
+			{name: "pbes2 smuggled", cookie: forge(`{"alg":"PBES2-HS256+A128KW","Alg":"A256GCMKW","enc":"A256GCM","kid":"new",` + p2c + `}`)},
+			{name: "other content encryption", cookie: encrypt(newKey, jwx.A128GCM)},
+			{name: "other key algorithm", cookie: encrypt(jwx.NewSymmetricKey("new", jwx.DIRECT, jwx.UseEnc, bytes.Repeat([]byte{2}, 32)), jwx.A256GCM)},
+		} {
+			t.Run(c.name, func(t *testing.T) {
+				m := newManager(t, "new", tigasdk.NewMemorySessionStore(0))
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.AddCookie(&http.Cookie{Name: "tiga_session", Value: c.cookie})
+
+				start := time.Now()
+				_, err := m.Load(httptest.NewRecorder(), r)
+				assert.Equal(t, tigasdk.ErrInvalidSession, err)
+				assert.NoError(t, m.Clear(httptest.NewRecorder(), r))
+				assert.True(t, time.Since(start) < time.Second)
+			})
+		}
+	})
+}
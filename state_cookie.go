@@ -0,0 +1,117 @@
+package tigasdk
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultStateCookieName   = "tiga_state"
+	defaultStateCookieMaxAge = 10 * time.Minute
+)
+
+var (
+	ErrStateNotBound = errors.New("state is not bound to the user agent")
+)
+
+// StateCookieOpt is the options of the cookie which binds the state of an authorization request to the user agent
+// of the End-User. Without the binding, an attacker could complete the login with a state and code of their own
+// in the End-User's browser, signing the End-User into the attacker's account (login CSRF).
+type StateCookieOpt struct {
+	// Name is the prefix of the state cookie names. Each state is bound by its own cookie, so that concurrent
+	// logins in several tabs do not interfere. When empty, "tiga_state" is used.
+	Name string
+
+	// Path is the path attribute of the state cookies, which must cover the redirect uri. When empty, "/" is used.
+	Path string
+
+	// Domain is the optional domain attribute of the state cookies.
+	Domain string
+
+	// SameSite is the same site attribute of the state cookies. When unset, http.SameSiteLaxMode is used, which is
+	// sent along the redirect to the redirect uri. Set http.SameSiteNoneMode for the form_post response modes, as
+	// the cross site form post would not carry a lax cookie.
+	SameSite http.SameSite
+
+	// Insecure allows state cookies to be sent over plain http. It is intended for local development only.
+	Insecure bool
+
+	// MaxAge is the lifetime of the state cookies. When zero, 10 minutes is used.
+	MaxAge time.Duration
+}
+
+// SetStateCookie sets the short-lived cookie which binds the state to the user agent of the End-User. It must be
+// called on the response redirecting the End-User to the authorization endpoint, with the same options given to
+// CallbackOpt.StateCookie. The cookie holds the hash of the state, rather than the state itself.
+func SetStateCookie(rw http.ResponseWriter, state string, opt *StateCookieOpt) {
+	opt = opt.withDefaults()
+	hash := stateHash(state)
+	http.SetCookie(rw, opt.cookie(hash, hash, int(opt.MaxAge.Seconds())))
+}
+
+// checkStateCookie checks that the request carries the cookie set by SetStateCookie for the state, and clears the
+// cookie. ErrStateNotBound is returned if the cookie is absent or does not match.
+func checkStateCookie(rw http.ResponseWriter, r *http.Request, state string, opt *StateCookieOpt) error {
+	opt = opt.withDefaults()
+
+	hash := stateHash(state)
+	c, err := r.Cookie(opt.cookieName(hash))
+	if err != nil {
+		return ErrStateNotBound
+	}
+
+	http.SetCookie(rw, opt.cookie(hash, "", -1))
+
+	if subtle.ConstantTimeCompare([]byte(c.Value), []byte(hash)) != 1 {
+		return ErrStateNotBound
+	}
+
+	return nil
+}
+
+func (o *StateCookieOpt) withDefaults() *StateCookieOpt {
+	opt := new(StateCookieOpt)
+	if o != nil {
+		*opt = *o
+	}
+	if len(opt.Name) == 0 {
+		opt.Name = defaultStateCookieName
+	}
+	if len(opt.Path) == 0 {
+		opt.Path = "/"
+	}
+	if opt.SameSite == 0 {
+		opt.SameSite = http.SameSiteLaxMode
+	}
+	if opt.MaxAge <= 0 {
+		opt.MaxAge = defaultStateCookieMaxAge
+	}
+	return opt
+}
+
+// cookieName names the cookie of the state by a prefix of its hash.
+func (o *StateCookieOpt) cookieName(hash string) string {
+	return o.Name + "." + hash[:16]
+}
+
+func (o *StateCookieOpt) cookie(hash string, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     o.cookieName(hash),
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		MaxAge:   maxAge,
+		Secure:   !o.Insecure,
+		HttpOnly: true,
+		SameSite: o.SameSite,
+	}
+}
+
+func stateHash(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,59 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"time"
+)
+
+const (
+	defaultStateStoreSize = 10000
+	defaultStateStoreTTL  = 10 * time.Minute
+)
+
+var (
+	ErrStateNotFound = errors.New("authorize session is not found for state")
+)
+
+// StateStore persists the AuthorizeSession between the redirect to the authorization endpoint and the
+// authorization response at the redirect uri.
+type StateStore interface {
+	// Save persists the session, keyed by its state.
+	Save(ctx context.Context, session *AuthorizeSession) error
+
+	// Take loads the session by state and removes it, so that each session can only be used once. When the
+	// session does not exist or has expired, ErrStateNotFound is returned.
+	Take(ctx context.Context, state string) (*AuthorizeSession, error)
+}
+
+// NewMemoryStateStore creates a StateStore which keeps at most size sessions in memory, each for ttl since it
+// was created. When non-positive, size defaults to 10000, and ttl defaults to 10 minutes. It is only suitable
+// for single instance deployments.
+func NewMemoryStateStore(size int, ttl time.Duration) StateStore {
+	if size <= 0 {
+		size = defaultStateStoreSize
+	}
+	if ttl <= 0 {
+		ttl = defaultStateStoreTTL
+	}
+	return &memoryStateStore{cache: internal.NewCache(size), ttl: ttl}
+}
+
+type memoryStateStore struct {
+	cache *internal.Cache
+	ttl   time.Duration
+}
+
+func (m *memoryStateStore) Save(_ context.Context, session *AuthorizeSession) error {
+	m.cache.Put(session.State, session, time.Unix(session.CreatedAt, 0).Add(m.ttl))
+	return nil
+}
+
+func (m *memoryStateStore) Take(_ context.Context, state string) (*AuthorizeSession, error) {
+	v, ok := m.cache.Take(state)
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return v.(*AuthorizeSession), nil
+}
@@ -15,6 +15,9 @@ type Stub interface {
 	// TokenByCode acquire access tokens, and optionally refresh token and id_token using the authorization_code flow.
 	TokenByCode(ctx context.Context, code string, redirectURI string, scopes []string) (*TokenResponse, error)
 
+	// TokenByPassword acquire access tokens, and optionally refresh token using the resource owner password flow.
+	TokenByPassword(ctx context.Context, username string, password string, scopes []string) (*TokenResponse, error)
+
 	// TokenByRefreshToken acquire access tokens and refresh token by exchanging in existing refresh token.
 	TokenByRefreshToken(ctx context.Context, refreshToken string, scopes []string) (*TokenResponse, error)
 
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"github.com/absurdlab/tiga-go-sdk/internal"
 	"github.com/absurdlab/tiga-go-sdk/jwx"
 	"github.com/absurdlab/tiga-go-sdk/oidc"
 	"github.com/imulab/coldcall"
@@ -12,16 +13,21 @@ import (
 	"github.com/imulab/coldcall/header"
 	"github.com/imulab/coldcall/status"
 	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
+const clientAssertionTypeJwtBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
 var (
-	ErrUnexpectedResponse = errors.New("sdk received unexpected response")
+	ErrUnexpectedResponse    = errors.New("sdk received unexpected response")
+	ErrUnsupportedAuthMethod = errors.New("token endpoint authentication method is not configured or not supported")
 )
 
 func (s *SDK) TokenByClientCredentials(ctx context.Context, scopes []string) (*TokenResponse, error) {
-	options, err := s.createTokenRequest(map[string]string{
+	options, err := s.createTokenRequest(ctx, map[string]string{
 		"client_id":  s.clientId,
 		"grant_type": oidc.GrantTypeClientCredentials,
 		"scope":      strings.Join(scopes, " "),
@@ -34,12 +40,36 @@ func (s *SDK) TokenByClientCredentials(ctx context.Context, scopes []string) (*T
 }
 
 func (s *SDK) TokenByCode(ctx context.Context, code string, redirectURI string, scopes []string) (*TokenResponse, error) {
-	options, err := s.createTokenRequest(map[string]string{
-		"client_id":    s.clientId,
-		"redirect_uri": redirectURI,
-		"grant_type":   oidc.GrantTypeAuthorizationCode,
-		"scope":        strings.Join(scopes, " "),
-		"code":         code,
+	return s.TokenByCodeWithPKCE(ctx, code, redirectURI, "", scopes)
+}
+
+// TokenByCodeWithPKCE is the same as TokenByCode, except that it also sends the PKCE code_verifier, which
+// matches the code_challenge sent in the authorize request. It is required for public clients.
+func (s *SDK) TokenByCodeWithPKCE(ctx context.Context, code string, redirectURI string, codeVerifier string, scopes []string) (*TokenResponse, error) {
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":     s.clientId,
+		"redirect_uri":  redirectURI,
+		"grant_type":    oidc.GrantTypeAuthorizationCode,
+		"scope":         strings.Join(scopes, " "),
+		"code":          code,
+		"code_verifier": codeVerifier,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeTokenRequest(ctx, options)
+}
+
+// TokenByPassword acquire access tokens using the resource owner password credentials. It exists for legacy and
+// migration use cases only, as the client handles the End-User's credentials directly.
+func (s *SDK) TokenByPassword(ctx context.Context, username string, password string, scopes []string) (*TokenResponse, error) {
+	options, err := s.createTokenRequest(ctx, map[string]string{
+		"client_id":  s.clientId,
+		"grant_type": oidc.GrantTypePassword,
+		"scope":      strings.Join(scopes, " "),
+		"username":   username,
+		"password":   password,
 	})
 	if err != nil {
 		return nil, err
@@ -49,7 +79,7 @@ func (s *SDK) TokenByCode(ctx context.Context, code string, redirectURI string,
 }
 
 func (s *SDK) TokenByRefreshToken(ctx context.Context, refreshToken string, scopes []string) (*TokenResponse, error) {
-	options, err := s.createTokenRequest(map[string]string{
+	options, err := s.createTokenRequest(ctx, map[string]string{
 		"client_id":     s.clientId,
 		"grant_type":    oidc.GrantTypeRefreshToken,
 		"scope":         strings.Join(scopes, " "),
@@ -62,33 +92,62 @@ func (s *SDK) TokenByRefreshToken(ctx context.Context, refreshToken string, scop
 	return s.executeTokenRequest(ctx, options)
 }
 
-func (s *SDK) createTokenRequest(initial map[string]string) ([]coldcall.Option, error) {
+func (s *SDK) createTokenRequest(ctx context.Context, initial map[string]string) ([]coldcall.Option, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var options []coldcall.Option
 
 	switch s.authMethod {
 	case oidc.ClientSecretBasic:
+		// RFC 6749 section 2.3.1: id and secret are form url encoded before joined with ":"
 		options = append(options, header.Custom(
 			"Authorization",
-			base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", s.clientId, s.clientSecret)))),
-		)
+			"Basic "+base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(
+				"%s:%s",
+				url.QueryEscape(s.clientId),
+				url.QueryEscape(s.clientSecret),
+			))),
+		))
 	case oidc.ClientSecretPost:
 		initial["client_secret"] = s.clientSecret
+	case oidc.ClientSecretJwt:
+		if err := jwx.ValidSignatureAlg(s.authSigAlg); err != nil || !strings.HasPrefix(s.authSigAlg, "HS") {
+			return nil, jwx.ErrInvalidSignatureAlg
+		}
+		secretKey := jwx.NewSymmetricKey("", s.authSigAlg, jwx.UseSig, []byte(s.clientSecret))
+		if assertion, err := s.clientAssertion(jwx.SignatureKeyByAlg(s.authSigAlg, jwx.NewKeySet(secretKey)), discovery.TokenEndpoint); err != nil {
+			return nil, err
+		} else {
+			initial["client_assertion_type"] = clientAssertionTypeJwtBearer
+			initial["client_assertion"] = assertion
+		}
 	case oidc.PrivateKeyJwt:
-		initial["client_assertion_type"] = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
-		if assertion, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(s.authSigAlg, s.clientJwks), jwx.SkipKeySource, jwt.Claims{
-			Issuer:    s.clientId,
-			Subject:   s.clientId,
-			Audience:  []string{s.discovery.TokenEndpoint},
-			Expiry:    jwt.NewNumericDate(time.Now().Add(10 * time.Second)),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		}); err != nil {
+		alg, err := s.clientSigAlg(s.authSigAlg, discovery.TokenEndpointAuthSigningAlgValuesSupported)
+		if err != nil {
+			return nil, err
+		}
+		if assertion, err := s.clientAssertion(jwx.SignatureKeyByAlg(alg, s.clientJwks), discovery.TokenEndpoint); err != nil {
 			return nil, err
 		} else {
+			initial["client_assertion_type"] = clientAssertionTypeJwtBearer
 			initial["client_assertion"] = assertion
 		}
+	case oidc.TokenAuthNone:
+		// public client, client_id alone identifies the client
+	case oidc.TLSClientAuth, oidc.SelfSignedTLSClientAuth:
+		// client certificate is presented during TLS handshake
 	default:
-		panic("impossible auth method")
+		return nil, ErrUnsupportedAuthMethod
+	}
+
+	// optional parameters such as scope are omitted when empty
+	for k, v := range initial {
+		if len(v) == 0 {
+			delete(initial, k)
+		}
 	}
 
 	options = append(options, header.ContentType(header.ContentTypeApplicationFormUrlEncoded))
@@ -97,31 +156,112 @@ func (s *SDK) createTokenRequest(initial map[string]string) ([]coldcall.Option,
 	return options, nil
 }
 
+// clientAssertion creates a client_assertion signed by the given KeySource, intended for the audience.
+func (s *SDK) clientAssertion(sig jwx.KeySource, audience string) (string, error) {
+	return s.assertion(sig, s.clientId, audience)
+}
+
+// assertion creates a short-lived JWT issued by the client about the subject, signed by the given KeySource and
+// intended for the audience.
+func (s *SDK) assertion(sig jwx.KeySource, subject string, audience string) (string, error) {
+	return jwx.EncodeToString(sig, jwx.SkipKeySource, jwt.Claims{
+		ID:        internal.RandomString(16),
+		Issuer:    s.clientId,
+		Subject:   subject,
+		Audience:  []string{audience},
+		Expiry:    jwt.NewNumericDate(time.Now().Add(10 * time.Second)),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	})
+}
+
 func (s *SDK) executeTokenRequest(ctx context.Context, options []coldcall.Option) (*TokenResponse, error) {
-	req, err := coldcall.Post(ctx, s.discovery.TokenEndpoint, options...)
+	discovery, err := s.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var successConstructor coldcall.Constructor = func() interface{} { return new(TokenResponse) }
+
+	result, err := s.postFormWithProof(ctx, discovery.TokenEndpoint, options, body.JSONUnmarshal(successConstructor))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*TokenResponse), nil
+}
+
+// postForm posts the request created by createTokenRequest to the endpoint, and reads the response with
+// successProducer if status is 2XX. Failure responses are returned as *ErrorResponse error.
+func (s *SDK) postForm(ctx context.Context, endpoint string, options []coldcall.Option, successProducer coldcall.Producer) (interface{}, error) {
+	req, err := coldcall.Post(ctx, endpoint, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		successConstructor coldcall.Constructor = func() interface{} { return new(TokenResponse) }
-		failureConstructor coldcall.Constructor = func() interface{} { return new(ErrorResponse) }
-	)
+	result, _, err := s.sendForm(req, successProducer)
+	return result, err
+}
+
+// postFormWithProof is the same as postForm, except that a DPoP proof is attached when WithDPoP is set. If the
+// server demands a DPoP nonce, the request is retried once with a proof carrying the nonce.
+func (s *SDK) postFormWithProof(ctx context.Context, endpoint string, options []coldcall.Option, successProducer coldcall.Producer) (interface{}, error) {
+	if s.dpop == nil {
+		return s.postForm(ctx, endpoint, options, successProducer)
+	}
 
-	result, _, err := coldcall.Response(s.httpClient.Do(req)).
-		Expect(status.Is200, body.JSONUnmarshal(successConstructor)).
-		Expect(status.IsFailure, body.JSONUnmarshal(failureConstructor)).
-		Read()
+	req, err := coldcall.Post(ctx, endpoint, options...)
 	if err != nil {
 		return nil, err
 	}
 
+	for attempt := 0; ; attempt++ {
+		proof, err := s.dpop.proof(req.Method, req.URL, "")
+		if err != nil {
+			return nil, err
+		}
+
+		r := req.Clone(ctx)
+		if attempt > 0 && req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		r.Header.Set(headerDPoP, proof)
+
+		result, resp, err := s.sendForm(r, successProducer)
+		s.dpop.saveNonce(resp)
+
+		if e, ok := err.(*ErrorResponse); ok && e.Code == oidc.ErrorCodeUseDPoPNonce && attempt == 0 {
+			continue
+		}
+
+		return result, err
+	}
+}
+
+// sendForm sends the request and reads the response as described in postForm. The original response is also
+// returned, or nil if the request failed.
+func (s *SDK) sendForm(req *http.Request, successProducer coldcall.Producer) (interface{}, *http.Response, error) {
+	var failureConstructor coldcall.Constructor = func() interface{} { return new(ErrorResponse) }
+
+	resp := coldcall.Response(s.httpClient.Do(req)).
+		Expect(status.IsSuccess, successProducer).
+		Expect(status.IsFailure, body.JSONUnmarshal(failureConstructor))
+
+	result, _, err := resp.Read()
+	if err != nil {
+		return nil, resp.Original(), err
+	}
+
 	switch result.(type) {
-	case *TokenResponse:
-		return result.(*TokenResponse), nil
 	case *ErrorResponse:
-		return nil, result.(*ErrorResponse)
+		e := result.(*ErrorResponse)
+		e.Status = resp.Original().StatusCode
+		return nil, resp.Original(), e
+	case []byte:
+		return nil, resp.Original(), ErrUnexpectedResponse
 	default:
-		return nil, ErrUnexpectedResponse
+		return result, resp.Original(), nil
 	}
 }
@@ -0,0 +1,128 @@
+package tigasdk
+
+import (
+	"context"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenExpiryLeeway is the period before the access token expiry when a cached token is no longer reused.
+	tokenExpiryLeeway = 10 * time.Second
+	// tokenFetchTimeout is the upper bound of a shared round trip to the token endpoint.
+	tokenFetchTimeout = 30 * time.Second
+)
+
+// tokenCache caches TokenResponse by the set of requested scopes.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]*cachedToken
+	flight internal.Flight
+}
+
+type cachedToken struct {
+	resp   *TokenResponse
+	expiry time.Time
+}
+
+// ClientCredentialsToken returns an access token acquired through the client_credentials flow. Unlike
+// TokenByClientCredentials, access tokens are cached by the set of requested scopes and reused until shortly
+// before they expire. Concurrent calls requesting the same scopes share a single round trip to the token endpoint,
+// which is not canceled when any one caller's ctx is done; each caller stops waiting when its own ctx is done.
+//
+// The returned TokenResponse is a copy whose ExpiresIn reflects the remaining lifetime of the access token.
+func (s *SDK) ClientCredentialsToken(ctx context.Context, scopes []string) (*TokenResponse, error) {
+	key := scopeKey(scopes)
+
+	if tr, ok := s.tokenCache.get(key); ok {
+		return tr, nil
+	}
+
+	v, err := s.tokenCache.flight.DoContext(ctx, key, func() (interface{}, error) {
+		if tr, ok := s.tokenCache.get(key); ok {
+			return tr, nil
+		}
+
+		ctx, cancel := context.WithTimeout(internal.Detach(ctx), tokenFetchTimeout)
+		defer cancel()
+
+		tr, err := s.TokenByClientCredentials(ctx, scopes)
+		if err != nil {
+			return nil, err
+		}
+
+		var lifespan time.Duration
+		if tr.ExpiresIn != nil {
+			lifespan = time.Duration(*tr.ExpiresIn) * time.Second
+		} else if discovery, err := s.getDiscovery(ctx); err == nil {
+			lifespan = discovery.AccessTokenLifespanDuration()
+		}
+
+		if lifespan > tokenExpiryLeeway {
+			s.tokenCache.put(key, tr, time.Now().Add(lifespan))
+		}
+
+		return tr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copyTokenResponse(v.(*TokenResponse)), nil
+}
+
+func (c *tokenCache) get(key string) (*TokenResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tokens[key]
+	if !ok {
+		return nil, false
+	}
+
+	remaining := time.Until(t.expiry)
+	if remaining <= tokenExpiryLeeway {
+		delete(c.tokens, key)
+		return nil, false
+	}
+
+	cp := *t.resp
+	expiresIn := int64(remaining / time.Second)
+	cp.ExpiresIn = &expiresIn
+
+	return &cp, true
+}
+
+func (c *tokenCache) put(key string, tr *TokenResponse, expiry time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens == nil {
+		c.tokens = map[string]*cachedToken{}
+	}
+	c.tokens[key] = &cachedToken{resp: copyTokenResponse(tr), expiry: expiry}
+}
+
+// copyTokenResponse returns a copy of tr which shares nothing with it, so that callers cannot alter cached responses.
+func copyTokenResponse(tr *TokenResponse) *TokenResponse {
+	cp := *tr
+	if tr.ExpiresIn != nil {
+		expiresIn := *tr.ExpiresIn
+		cp.ExpiresIn = &expiresIn
+	}
+	return &cp
+}
+
+// scopeKey returns a canonical representation of the set of scopes, regardless of order and duplication.
+func scopeKey(scopes []string) string {
+	set := internal.NewSet(scopes...)
+	keys := make([]string, 0, len(set))
+	for scope := range set {
+		keys = append(keys, scope)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
@@ -0,0 +1,135 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSDK_ClientCredentialsToken(t *testing.T) {
+	var issued int32
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&issued, 1)
+			time.Sleep(10 * time.Millisecond)
+			expiresIn := int64(3600)
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{
+				AccessToken: fmt.Sprintf("token-%d", n),
+				TokenType:   "Bearer",
+				ExpiresIn:   &expiresIn,
+				Scope:       r.PostFormValue("scope"),
+			})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tr, err := sdk.ClientCredentialsToken(context.Background(), []string{"foo", "bar"})
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", tr.AccessToken)
+		}()
+	}
+	wg.Wait()
+
+	tr, err := sdk.ClientCredentialsToken(context.Background(), []string{"bar", "foo", "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", tr.AccessToken)
+
+	tr, err = sdk.ClientCredentialsToken(context.Background(), []string{"foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", tr.AccessToken)
+}
+
+func TestSDK_ClientCredentialsTokenCanceledCaller(t *testing.T) {
+	var issued int32
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&issued, 1)
+			time.Sleep(100 * time.Millisecond)
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer"})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := sdk.ClientCredentialsToken(ctx, []string{"foo"})
+		canceled <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	waited := make(chan *tigasdk.TokenResponse, 1)
+	go func() {
+		tr, err := sdk.ClientCredentialsToken(context.Background(), []string{"foo"})
+		assert.NoError(t, err)
+		waited <- tr
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.Equal(t, context.Canceled, <-canceled)
+	if tr := <-waited; assert.NotNil(t, tr) {
+		assert.Equal(t, "token", tr.AccessToken)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&issued))
+}
+
+func TestSDK_ClientCredentialsTokenReturnsCopy(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			expiresIn := int64(3600)
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer", ExpiresIn: &expiresIn})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		tr, err := sdk.ClientCredentialsToken(context.Background(), []string{"foo"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "token", tr.AccessToken)
+		if assert.NotNil(t, tr.ExpiresIn) {
+			assert.Greater(t, *tr.ExpiresIn, int64(3000))
+		}
+
+		tr.AccessToken = "altered"
+		*tr.ExpiresIn = 0
+	}
+}
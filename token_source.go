@@ -0,0 +1,192 @@
+package tigasdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTokenExpired = errors.New("access token has expired and cannot be refreshed")
+)
+
+// TokenSourceOpt is the options for TokenSource.
+type TokenSourceOpt struct {
+	// Scopes is the list of scopes to request when refreshing the access token.
+	// When empty or nil, the scope parameter is omitted so that the originally
+	// granted scopes are retained.
+	Scopes []string
+
+	// Expiry is the absolute expiry of the access token. When zero, it is computed
+	// from the ExpiresIn of the initial TokenResponse. This is useful when restoring
+	// a previously persisted token.
+	Expiry time.Time
+
+	// OnRefresh is called with the new TokenResponse every time the access token is
+	// refreshed. If the refresh token was rotated, the response carries the new refresh
+	// token. It is intended for persisting the refreshed tokens.
+	OnRefresh func(*TokenResponse)
+}
+
+// TokenSource supplies valid access tokens of an End-User, acquired by TokenByCode or other user flows. It
+// refreshes the access token with the refresh token shortly before it expires. TokenSource is safe for
+// concurrent use.
+type TokenSource struct {
+	sdk    *SDK
+	opt    *TokenSourceOpt
+	mu     sync.Mutex
+	token  *TokenResponse
+	expiry time.Time
+}
+
+// TokenSource returns a new TokenSource that starts from the given TokenResponse.
+func (s *SDK) TokenSource(tr *TokenResponse, opt *TokenSourceOpt) *TokenSource {
+	if opt == nil {
+		opt = &TokenSourceOpt{}
+	}
+
+	ts := &TokenSource{sdk: s, opt: opt, token: tr, expiry: opt.Expiry}
+	if ts.expiry.IsZero() && tr.ExpiresIn != nil {
+		ts.expiry = time.Now().Add(time.Duration(*tr.ExpiresIn) * time.Second)
+	}
+
+	return ts
+}
+
+// Token returns a valid access token. If the current access token has expired or is about to expire, it is
+// refreshed first. When the access token cannot be refreshed because no refresh token was issued,
+// ErrTokenExpired is returned.
+//
+// The returned TokenResponse is a copy whose ExpiresIn reflects the remaining lifetime of the access token.
+func (ts *TokenSource) Token(ctx context.Context) (*TokenResponse, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.expiry.IsZero() || time.Until(ts.expiry) > tokenExpiryLeeway {
+		return ts.current(), nil
+	}
+
+	if len(ts.token.RefreshToken) == 0 {
+		return nil, ErrTokenExpired
+	}
+
+	tr, err := ts.sdk.TokenByRefreshToken(ctx, ts.token.RefreshToken, ts.opt.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tiga may or may not rotate the refresh token, or issue a new id token,
+	// retain the previous ones if they are absent in the response.
+	if len(tr.RefreshToken) == 0 {
+		tr.RefreshToken = ts.token.RefreshToken
+	}
+	if len(tr.IdToken) == 0 {
+		tr.IdToken = ts.token.IdToken
+	}
+
+	ts.token = tr
+	ts.expiry = time.Time{}
+	if tr.ExpiresIn != nil {
+		ts.expiry = time.Now().Add(time.Duration(*tr.ExpiresIn) * time.Second)
+	}
+
+	if ts.opt.OnRefresh != nil {
+		ts.opt.OnRefresh(ts.current())
+	}
+
+	return ts.current(), nil
+}
+
+// Expiry returns the absolute expiry of the current access token. Zero time is returned if the expiry is unknown.
+func (ts *TokenSource) Expiry() time.Time {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.expiry
+}
+
+func (ts *TokenSource) current() *TokenResponse {
+	cp := *ts.token
+	if !ts.expiry.IsZero() {
+		expiresIn := int64(time.Until(ts.expiry) / time.Second)
+		cp.ExpiresIn = &expiresIn
+	}
+	return &cp
+}
+
+// RoundTripper returns a http.RoundTripper that attaches a valid access token in the "Authorization" header
+// of every outbound request. If base is nil, http.DefaultTransport is used to carry out the request.
+//
+// When the sdk was created WithDPoP and the access token is DPoP bound, a fresh DPoP proof is attached to every
+// request as well. If the resource server demands a DPoP nonce, requests with replayable body are retried once.
+func (ts *TokenSource) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tokenTransport{source: ts, base: base}
+}
+
+type tokenTransport struct {
+	source *TokenSource
+	base   http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	tr, err := t.source.Token(r.Context())
+	if err != nil {
+		// RoundTripper must always close the request body, even on errors.
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+		return nil, err
+	}
+
+	dpop := t.source.sdk.dpop
+	if dpop == nil || !strings.EqualFold(tr.TokenType, DPoPTokenType) {
+		// RoundTripper must not modify the request, work on a copy.
+		r2 := r.Clone(r.Context())
+		r2.Header.Set("Authorization", AccessTokenType+" "+tr.AccessToken)
+		return t.base.RoundTrip(r2)
+	}
+
+	resp, err := t.roundTripDPoP(r, r.Body, dpop, tr.AccessToken)
+	if err != nil || !isNonceChallenge(resp) || (r.Body != nil && r.GetBody == nil) {
+		return resp, err
+	}
+
+	_ = resp.Body.Close()
+
+	var rewound io.ReadCloser
+	if r.GetBody != nil {
+		if rewound, err = r.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.roundTripDPoP(r, rewound, dpop, tr.AccessToken)
+}
+
+func (t *tokenTransport) roundTripDPoP(r *http.Request, body io.ReadCloser, dpop *dpopSigner, accessToken string) (*http.Response, error) {
+	proof, err := dpop.proof(r.Method, r.URL, accessToken)
+	if err != nil {
+		if body != nil {
+			_ = body.Close()
+		}
+		return nil, err
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.Body = body
+	r2.Header.Set("Authorization", DPoPTokenType+" "+accessToken)
+	r2.Header.Set(headerDPoP, proof)
+
+	resp, err := t.base.RoundTrip(r2)
+	if err == nil {
+		dpop.saveNonce(resp)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,108 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTokenSource(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "refresh_token", r.PostFormValue("grant_type"))
+			assert.Equal(t, "refresh-1", r.PostFormValue("refresh_token"))
+			expiresIn := int64(3600)
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{
+				AccessToken:  "access-2",
+				TokenType:    "Bearer",
+				ExpiresIn:    &expiresIn,
+				RefreshToken: "refresh-2",
+			})
+		},
+	})
+	defer srv.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer api.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	var (
+		expiresIn = int64(1)
+		refreshed *tigasdk.TokenResponse
+	)
+	ts := sdk.TokenSource(&tigasdk.TokenResponse{
+		AccessToken:  "access-1",
+		TokenType:    "Bearer",
+		ExpiresIn:    &expiresIn,
+		RefreshToken: "refresh-1",
+		IdToken:      "id-1",
+	}, &tigasdk.TokenSourceOpt{
+		OnRefresh: func(tr *tigasdk.TokenResponse) { refreshed = tr },
+	})
+
+	client := &http.Client{Transport: ts.RoundTripper(nil)}
+	resp, err := client.Get(api.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	auth, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer access-2", string(auth))
+
+	if assert.NotNil(t, refreshed) {
+		assert.Equal(t, "refresh-2", refreshed.RefreshToken)
+		assert.Equal(t, "id-1", refreshed.IdToken)
+	}
+
+	tr, err := ts.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "access-2", tr.AccessToken)
+}
+
+func TestTokenSource_RoundTripperClosesBodyOnError(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), nil)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	expiresIn := int64(1)
+	ts := sdk.TokenSource(&tigasdk.TokenResponse{AccessToken: "access-1", TokenType: "Bearer", ExpiresIn: &expiresIn}, nil)
+
+	body := &closeRecorder{Reader: strings.NewReader("payload")}
+	r := httptest.NewRequest(http.MethodPost, "https://api.test/", nil)
+	r.Body = body
+
+	_, err = ts.RoundTripper(nil).RoundTrip(r)
+	assert.Equal(t, tigasdk.ErrTokenExpired, err)
+	assert.True(t, body.closed)
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,173 @@
+package tigasdk_test
+
+import (
+	"context"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSDK_TokenEndpointAuthentication(t *testing.T) {
+	var verify func(t *testing.T, r *http.Request)
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			verify(t, r)
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer"})
+		},
+	})
+	defer srv.Close()
+
+	clientJwks := jwx.NewKeySet(jwx.GenerateSignatureKey("client-key", jwx.ES256, 0))
+
+	for _, c := range []struct {
+		name   string
+		option tigasdk.Option
+		verify func(t *testing.T, r *http.Request)
+	}{
+		{
+			name:   "client_secret_basic",
+			option: tigasdk.WithClientSecretBasic("client", "s3cr:t"),
+			verify: func(t *testing.T, r *http.Request) {
+				id, secret, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "client", id)
+				assert.Equal(t, "s3cr%3At", secret)
+			},
+		},
+		{
+			name:   "client_secret_basic with reserved characters",
+			option: tigasdk.WithClientSecretBasic("client:1%", "p%40ss:w%rd"),
+			verify: func(t *testing.T, r *http.Request) {
+				assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Basic "))
+
+				id, secret, ok := r.BasicAuth()
+				if assert.True(t, ok) {
+					id, _ = url.QueryUnescape(id)
+					secret, _ = url.QueryUnescape(secret)
+					assert.Equal(t, "client:1%", id)
+					assert.Equal(t, "p%40ss:w%rd", secret)
+				}
+			},
+		},
+		{
+			name:   "client_secret_jwt",
+			option: tigasdk.WithClientSecretJwt("client", "a-sufficiently-long-client-secret", jwx.HS384),
+			verify: func(t *testing.T, r *http.Request) {
+				assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", r.PostFormValue("client_assertion_type"))
+
+				key := jwx.NewSymmetricKey("", jwx.HS384, jwx.UseSig, []byte("a-sufficiently-long-client-secret"))
+				claims := new(jwt.Claims)
+				err := jwx.Decode(r.PostFormValue("client_assertion"), jwx.NewKeySet(key), nil, jwx.Algs{Sig: jwx.HS384}, claims)
+				if assert.NoError(t, err) {
+					assert.Equal(t, "client", claims.Subject)
+					assert.NotEmpty(t, claims.ID)
+				}
+			},
+		},
+		{
+			name:   "private_key_jwt",
+			option: tigasdk.WithPrivateKeyJwt("client", clientJwks, jwx.ES256),
+			verify: func(t *testing.T, r *http.Request) {
+				assert.Empty(t, r.Header.Get("Authorization"))
+				assert.Empty(t, r.PostFormValue("client_secret"))
+				assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", r.PostFormValue("client_assertion_type"))
+
+				header, err := jwx.ReadHeader(r.PostFormValue("client_assertion"))
+				if assert.NoError(t, err) {
+					assert.Equal(t, jwx.ES256, header.Alg)
+					assert.Equal(t, "client-key", header.KeyId)
+				}
+
+				claims := new(jwt.Claims)
+				err = jwx.Decode(r.PostFormValue("client_assertion"), clientJwks.ToPublic(), nil, jwx.Algs{Sig: jwx.ES256}, claims)
+				if assert.NoError(t, err) {
+					assert.Equal(t, "client", claims.Issuer)
+					assert.Equal(t, "client", claims.Subject)
+					assert.True(t, claims.Audience.Contains(srv.URL+"/oauth/token"))
+					assert.NotEmpty(t, claims.ID)
+				}
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			verify = c.verify
+
+			sdk, err := tigasdk.NewWithContext(context.Background(), tigasdk.WithServiceBaseURL(srv.URL), c.option)
+			assert.NoError(t, err)
+
+			tr, err := sdk.TokenByClientCredentials(context.Background(), []string{"foo"})
+			assert.NoError(t, err)
+			assert.Equal(t, "token", tr.AccessToken)
+		})
+	}
+}
+
+func TestSDK_TokenByPassword(t *testing.T) {
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, oidc.GrantTypePassword, r.PostFormValue("grant_type"))
+			assert.Equal(t, "alice", r.PostFormValue("username"))
+			assert.Equal(t, "p@ssw0rd", r.PostFormValue("password"))
+			assert.Equal(t, "secret", r.PostFormValue("client_secret"))
+
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer"})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretPost("migration", "secret"),
+	)
+	assert.NoError(t, err)
+
+	tr, err := sdk.TokenByPassword(context.Background(), "alice", "p@ssw0rd", []string{"openid"})
+	assert.NoError(t, err)
+	assert.Equal(t, "token", tr.AccessToken)
+}
+
+func TestSDK_TokenByCodeWithPKCE_PublicClient(t *testing.T) {
+	verifier := oidc.NewCodeVerifier()
+
+	srv := newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+		"/oauth/token": func(rw http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			assert.Empty(t, r.Header.Get("Authorization"))
+			assert.Equal(t, "client", r.PostForm.Get("client_id"))
+			assert.Equal(t, verifier, r.PostForm.Get("code_verifier"))
+			assert.Equal(t, oidc.GrantTypeAuthorizationCode, r.PostForm.Get("grant_type"))
+			assert.Equal(t, "code", r.PostForm.Get("code"))
+			assert.Equal(t, "https://client.test/callback", r.PostForm.Get("redirect_uri"))
+			assert.NotContains(t, r.PostForm, "client_secret")
+			assert.NotContains(t, r.PostForm, "client_assertion")
+			assert.NotContains(t, r.PostForm, "scope")
+			for k, v := range r.PostForm {
+				assert.NotEmpty(t, v[0], k)
+			}
+
+			rw.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(rw).Encode(&tigasdk.TokenResponse{AccessToken: "token", TokenType: "Bearer"})
+		},
+	})
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+	)
+	assert.NoError(t, err)
+
+	tr, err := sdk.TokenByCodeWithPKCE(context.Background(), "code", "https://client.test/callback", verifier, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", tr.AccessToken)
+}
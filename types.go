@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/absurdlab/tiga-go-sdk/oidc"
 	"gopkg.in/square/go-jose.v2/jwt"
+	"time"
 )
 
 // Authentication is a End-User authentication record.
@@ -190,6 +192,20 @@ type AccessToken struct {
 	ClientId       string
 	Scopes         []string
 	UserInfoClaims map[string]interface{}
+
+	// Actor is the current actor of a delegated access token acquired by token exchange,
+	// or nil if the token was not delegated. Prior actors are nested in Actor.Actor.
+	Actor *Actor
+}
+
+// Actor is the "act" claim of a delegated access token, identifying the party acting on behalf of the subject.
+// A chain of delegation is expressed by nesting, where the outermost Actor is the current actor and the nested
+// ones are prior actors.
+type Actor struct {
+	Subject string `json:"sub"`
+	Issuer  string `json:"iss,omitempty"`
+	Client  string `json:"client_id,omitempty"`
+	Actor   *Actor `json:"act,omitempty"`
 }
 
 // AccessTokenClaims is the payload of a JWT encoded AccessToken issued by Tiga.
@@ -198,6 +214,18 @@ type AccessTokenClaims struct {
 	Client   string                 `json:"client"`
 	Scope    string                 `json:"scope"`
 	UserInfo map[string]interface{} `json:"userinfo,omitempty"`
+	Act      *Actor                 `json:"act,omitempty"`
+	Cnf      *Confirmation          `json:"cnf,omitempty"`
+}
+
+// Confirmation is the "cnf" claim of a sender-constrained access token, identifying the key that the client
+// must prove possession of when presenting the token.
+type Confirmation struct {
+	// Jkt is the JWK SHA-256 thumbprint of the DPoP key the token is bound to.
+	Jkt string `json:"jkt,omitempty"`
+
+	// X5tS256 is the SHA-256 thumbprint of the client certificate the token is bound to.
+	X5tS256 string `json:"x5t#S256,omitempty"`
 }
 
 func (c *AccessTokenClaims) Get(name string) (interface{}, bool) {
@@ -222,11 +250,78 @@ func (c *AccessTokenClaims) Get(name string) (interface{}, bool) {
 		return c.Scope, true
 	case "userinfo":
 		return c.UserInfo, true
+	case oidc.ClaimAct:
+		return c.Act, c.Act != nil
+	case oidc.ClaimCnf:
+		return c.Cnf, c.Cnf != nil
 	default:
 		return nil, false
 	}
 }
 
+// IdTokenClaims is the payload of an id token issued by Tiga.
+type IdTokenClaims struct {
+	jwt.Claims
+	Nonce    string   `json:"nonce,omitempty"`
+	AuthTime int64    `json:"auth_time,omitempty"`
+	Azp      string   `json:"azp,omitempty"`
+	Acr      string   `json:"acr,omitempty"`
+	Amr      []string `json:"amr,omitempty"`
+	AtHash   string   `json:"at_hash,omitempty"`
+	CHash    string   `json:"c_hash,omitempty"`
+
+	// Raw contains all claims in the id token, including those not
+	// mapped to the fields above, such as the profile claims.
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (c *IdTokenClaims) UnmarshalJSON(bytes []byte) error {
+	type plain IdTokenClaims
+	if err := json.Unmarshal(bytes, (*plain)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, &c.Raw)
+}
+
+func (c *IdTokenClaims) Get(name string) (interface{}, bool) {
+	switch name {
+	case jwx.ClaimJti:
+		return c.ID, true
+	case jwx.ClaimSub:
+		return c.Subject, true
+	case jwx.ClaimAud:
+		return []string(c.Audience), true
+	case jwx.ClaimExp:
+		return c.Expiry.Time(), true
+	case jwx.ClaimNbf:
+		return c.NotBefore.Time(), true
+	case jwx.ClaimIat:
+		return c.IssuedAt.Time(), true
+	case jwx.ClaimIss:
+		return c.Issuer, true
+	case oidc.ClaimNonce:
+		return c.Nonce, true
+	case oidc.ClaimAuthTime:
+		if c.AuthTime == 0 {
+			return time.Time{}, true
+		}
+		return time.Unix(c.AuthTime, 0), true
+	case oidc.ClaimAzp:
+		return c.Azp, true
+	case oidc.ClaimAcr:
+		return c.Acr, true
+	case oidc.ClaimAmr:
+		return c.Amr, true
+	case oidc.ClaimAtHash:
+		return c.AtHash, true
+	case oidc.ClaimCHash:
+		return c.CHash, true
+	default:
+		v, ok := c.Raw[name]
+		return v, ok
+	}
+}
+
 // TokenResponse is the response object at token endpoint.
 type TokenResponse struct {
 	AccessToken  string `json:"access_token,omitempty"`
@@ -235,6 +330,9 @@ type TokenResponse struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 	IdToken      string `json:"id_token,omitempty"`
 	Scope        string `json:"scope,omitempty"`
+
+	// IssuedTokenType is the type of the issued token in a token exchange response.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
 
 // ErrorResponse is the response object when error has occurred at token endpoint.
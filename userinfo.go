@@ -0,0 +1,267 @@
+package tigasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/absurdlab/tiga-go-sdk/internal"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/imulab/coldcall"
+	"github.com/imulab/coldcall/header"
+	"github.com/imulab/coldcall/status"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const contentTypeApplicationJwt = "application/jwt"
+
+var (
+	ErrUserInfoNotEncrypted  = errors.New("userinfo response is expected to be encrypted")
+	ErrInvalidUserInfoEncAlg = errors.New("userinfo encryption algorithms do not match the registered ones")
+	ErrInvalidUserInfoSigAlg = errors.New("userinfo signing algorithm is not supported")
+	ErrSubjectMismatch       = errors.New("userinfo sub claim does not match id token")
+)
+
+// Address is the End-User's preferred postal address.
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"street_address,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Country       string `json:"country,omitempty"`
+}
+
+// UserInfo is the standard claims returned from the userinfo endpoint.
+type UserInfo struct {
+	Subject             string   `json:"sub"`
+	Name                string   `json:"name,omitempty"`
+	GivenName           string   `json:"given_name,omitempty"`
+	FamilyName          string   `json:"family_name,omitempty"`
+	MiddleName          string   `json:"middle_name,omitempty"`
+	Nickname            string   `json:"nickname,omitempty"`
+	PreferredUsername   string   `json:"preferred_username,omitempty"`
+	Profile             string   `json:"profile,omitempty"`
+	Picture             string   `json:"picture,omitempty"`
+	Website             string   `json:"website,omitempty"`
+	Email               string   `json:"email,omitempty"`
+	EmailVerified       bool     `json:"email_verified,omitempty"`
+	Gender              string   `json:"gender,omitempty"`
+	Birthdate           string   `json:"birthdate,omitempty"`
+	ZoneInfo            string   `json:"zoneinfo,omitempty"`
+	Locale              string   `json:"locale,omitempty"`
+	PhoneNumber         string   `json:"phone_number,omitempty"`
+	PhoneNumberVerified bool     `json:"phone_number_verified,omitempty"`
+	Address             *Address `json:"address,omitempty"`
+	UpdatedAt           int64    `json:"updated_at,omitempty"`
+
+	// Raw contains all claims in the response, including those not
+	// mapped to the fields above.
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (u *UserInfo) UnmarshalJSON(bytes []byte) error {
+	type plain UserInfo
+	if err := json.Unmarshal(bytes, (*plain)(u)); err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, &u.Raw)
+}
+
+// UserInfo requests the userinfo endpoint with the access token. Plain JSON responses, as well as signed and/or
+// encrypted JWT responses are supported. Signed responses are verified against the Tiga jwks, and encrypted
+// responses are decrypted with keys set by WithClientJwks. When WithUserInfoEncryption is set, unencrypted
+// responses are rejected.
+//
+// When the sdk was created WithDPoP, tokens requested by the sdk are bound to the DPoP key, hence the access token
+// is presented with the "DPoP" scheme along with a fresh DPoP proof.
+//
+// If idToken is not nil, the "sub" claim of the response must match that of the id token, as required by the
+// specification. Otherwise, ErrSubjectMismatch is returned.
+func (s *SDK) UserInfo(ctx context.Context, accessToken string, idToken *IdTokenClaims) (*UserInfo, error) {
+	discovery, err := s.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, resp, err := s.requestUserInfo(ctx, discovery.UserInfoEndpoint, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := raw
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == contentTypeApplicationJwt {
+		if payload, err = s.decodeUserInfoJwt(ctx, string(raw)); err != nil {
+			return nil, err
+		}
+	} else if !jwx.IsNone(s.userInfoAlgs.Encrypt) {
+		return nil, ErrUserInfoNotEncrypted
+	}
+
+	userInfo := new(UserInfo)
+	if err := json.Unmarshal(payload, userInfo); err != nil {
+		return nil, err
+	}
+
+	if idToken != nil && userInfo.Subject != idToken.Subject {
+		return nil, ErrSubjectMismatch
+	}
+
+	return userInfo, nil
+}
+
+// requestUserInfo requests the userinfo endpoint with the access token, and returns the body of the successful
+// response. When WithDPoP is set, the access token is presented as a DPoP bound token along with a proof, and the
+// request is retried once if the endpoint demands a DPoP nonce.
+func (s *SDK) requestUserInfo(ctx context.Context, endpoint string, accessToken string) ([]byte, *http.Response, error) {
+	scheme := AccessTokenType
+	if s.dpop != nil {
+		scheme = DPoPTokenType
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := coldcall.Get(ctx, endpoint,
+			header.Custom("Authorization", scheme+" "+accessToken),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if s.dpop != nil {
+			proof, err := s.dpop.proof(req.Method, req.URL, accessToken)
+			if err != nil {
+				return nil, nil, err
+			}
+			req.Header.Set(headerDPoP, proof)
+		}
+
+		resp := coldcall.Response(s.httpClient.Do(req)).
+			Expect(status.IsFailure, bearerErrorProducer)
+
+		result, raw, err := resp.Read()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if s.dpop != nil {
+			s.dpop.saveNonce(resp.Original())
+			if attempt == 0 && isNonceChallenge(resp.Original()) {
+				continue
+			}
+		}
+
+		if e, ok := result.(*ErrorResponse); ok {
+			return nil, nil, e.withBearerChallenge(resp.Original())
+		}
+		if resp.Original().StatusCode != http.StatusOK {
+			return nil, nil, ErrUnexpectedResponse
+		}
+
+		return raw, resp.Original(), nil
+	}
+}
+
+// decodeUserInfoJwt decrypts and/or verifies the JWT userinfo response, and returns the JSON payload. Encrypted
+// responses must use the algorithms set by WithUserInfoEncryption. The "iss" and "aud" claims are validated for
+// signed responses.
+func (s *SDK) decodeUserInfoJwt(ctx context.Context, token string) ([]byte, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hd, err := jwx.ReadHeader(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if hd.IsEncrypted() {
+		if hd.Alg != s.userInfoAlgs.Encrypt || hd.Enc != s.userInfoAlgs.Encode {
+			return nil, ErrInvalidUserInfoEncAlg
+		}
+
+		decrypted, err := jwx.Decrypt(token, s.clientJwks)
+		if err != nil {
+			return nil, err
+		}
+
+		// a nested JWT is marked by the "cty" header; as the header is optional, a payload with a JOSE header is
+		// also taken as a nested JWT.
+		nested, err := jwx.ReadHeader(string(decrypted))
+		if err != nil || len(nested.Alg) == 0 {
+			if strings.EqualFold(hd.ContentType, jwx.ContentTypeJwt) {
+				return nil, jwx.ErrInvalidJwxToken
+			}
+			// encrypted only, payload is JSON
+			return decrypted, nil
+		}
+
+		token, hd = string(decrypted), nested
+	} else if !jwx.IsNone(s.userInfoAlgs.Encrypt) {
+		return nil, ErrUserInfoNotEncrypted
+	}
+
+	if jwx.IsNone(hd.Alg) {
+		return nil, ErrInvalidUserInfoSigAlg
+	}
+	if supported := discovery.UserInfoSigningAlgValuesSupported; len(supported) > 0 && !internal.NewSet(supported...).Contains(hd.Alg) {
+		return nil, ErrInvalidUserInfoSigAlg
+	}
+
+	var payload json.RawMessage
+	if err := s.decode(ctx, token, nil, jwx.Algs{Sig: hd.Alg}, &payload); err != nil {
+		return nil, err
+	}
+
+	var std struct {
+		Issuer   string       `json:"iss"`
+		Audience jwt.Audience `json:"aud"`
+	}
+	if err := json.Unmarshal(payload, &std); err != nil {
+		return nil, err
+	}
+	if len(std.Issuer) > 0 && std.Issuer != discovery.Issuer {
+		return nil, jwx.ErrInvalidIss
+	}
+	if len(std.Audience) > 0 && !std.Audience.Contains(s.clientId) {
+		return nil, jwx.ErrInvalidAud
+	}
+
+	return payload, nil
+}
+
+// bearerErrorProducer reads ErrorResponse from the response body. Resource servers may return the error in the
+// "WWW-Authenticate" header with an empty body, hence unmarshal error is ignored.
+var bearerErrorProducer coldcall.Producer = func(raw []byte) (interface{}, error) {
+	e := new(ErrorResponse)
+	_ = json.Unmarshal(raw, e)
+	return e, nil
+}
+
+var (
+	bearerChallengeError            = regexp.MustCompile(`\berror="([^"]*)"`)
+	bearerChallengeErrorDescription = regexp.MustCompile(`\berror_description="([^"]*)"`)
+)
+
+// withBearerChallenge fills the status, and the error code and description from the "WWW-Authenticate" header
+// of the response, if they were absent from the body.
+func (r *ErrorResponse) withBearerChallenge(resp *http.Response) *ErrorResponse {
+	r.Status = resp.StatusCode
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if len(r.Code) == 0 {
+		if m := bearerChallengeError.FindStringSubmatch(challenge); m != nil {
+			r.Code = m[1]
+		}
+	}
+	if len(r.Reason) == 0 {
+		if m := bearerChallengeErrorDescription.FindStringSubmatch(challenge); m != nil {
+			r.Reason = m[1]
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,245 @@
+package tigasdk_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	tigasdk "github.com/absurdlab/tiga-go-sdk"
+	"github.com/absurdlab/tiga-go-sdk/jwx"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSDK_UserInfo(t *testing.T) {
+	var (
+		tigaJwks = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		signed   bool
+		srv      = newTigaServer(tigaJwks, map[string]http.HandlerFunc{
+			"/userinfo": func(rw http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer access-token" {
+					rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="token is expired"`)
+					rw.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				claims := map[string]interface{}{
+					"sub":     "user",
+					"email":   "user@tiga.test",
+					"address": map[string]string{"country": "NZ"},
+					"custom":  "value",
+				}
+
+				if !signed {
+					rw.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(rw).Encode(claims)
+					return
+				}
+
+				claims["aud"] = "client"
+				token, _ := jwx.Encode(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, claims)
+				rw.Header().Set("Content-Type", "application/jwt")
+				_, _ = rw.Write(token)
+			},
+		})
+	)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("client", "secret"),
+	)
+	assert.NoError(t, err)
+
+	for _, s := range []bool{false, true} {
+		signed = s
+
+		userInfo, err := sdk.UserInfo(context.Background(), "access-token", nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "user", userInfo.Subject)
+			assert.Equal(t, "user@tiga.test", userInfo.Email)
+			assert.Equal(t, "NZ", userInfo.Address.Country)
+			assert.Equal(t, "value", userInfo.Raw["custom"])
+		}
+	}
+
+	_, err = sdk.UserInfo(context.Background(), "access-token", &tigasdk.IdTokenClaims{Raw: map[string]interface{}{}})
+	assert.Equal(t, tigasdk.ErrSubjectMismatch, err)
+
+	_, err = sdk.UserInfo(context.Background(), "expired", nil)
+	if assert.IsType(t, new(tigasdk.ErrorResponse), err) {
+		assert.Equal(t, http.StatusUnauthorized, err.(*tigasdk.ErrorResponse).Status)
+		assert.Equal(t, "invalid_token", err.(*tigasdk.ErrorResponse).Code)
+	}
+}
+
+func TestSDK_UserInfo_Encrypted(t *testing.T) {
+	var (
+		tigaJwks   = jwx.NewKeySet(jwx.GenerateSignatureKey("sig", jwx.ES256, 0))
+		clientJwks = jwx.NewKeySet(
+			jwx.GenerateEncryptionKey("enc", jwx.RSA_OAEP_256, 2048),
+			jwx.GenerateEncryptionKey("ec", jwx.ECDH_ES, 0),
+		)
+		encrypt  = jwx.EncryptionKeyByAlg(jwx.RSA_OAEP_256, jwx.A256GCM, clientJwks.ToPublic())
+		claims   = map[string]interface{}{"sub": "user", "aud": "client"}
+		response func(t *testing.T) []byte
+		srv      = newTigaServer(tigaJwks, map[string]http.HandlerFunc{
+			"/userinfo": func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set("Content-Type", "application/jwt")
+				_, _ = rw.Write(response(t))
+			},
+		})
+	)
+	defer srv.Close()
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithClientSecretBasic("client", "secret"),
+		tigasdk.WithClientJwks(clientJwks),
+		tigasdk.WithUserInfoEncryption(jwx.RSA_OAEP_256, jwx.A256GCM),
+	)
+	assert.NoError(t, err)
+
+	for _, c := range []struct {
+		name     string
+		response func(t *testing.T) []byte
+		invalid  bool
+		expect   error
+	}{
+		{
+			name: "encrypted json",
+			response: func(t *testing.T) []byte {
+				raw, _ := json.MarshalIndent(claims, "", "  ")
+				token, err := jwx.Encode(nil, encrypt, append([]byte("\n"), raw...))
+				assert.NoError(t, err)
+				return token
+			},
+		},
+		{
+			name: "encrypted jws",
+			response: func(t *testing.T) []byte {
+				token, err := jwx.Encode(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), encrypt, claims)
+				assert.NoError(t, err)
+				header, _ := jwx.ReadHeader(string(token))
+				assert.Equal(t, jwx.ContentTypeJwt, header.ContentType)
+				return token
+			},
+		},
+		{
+			name: "encrypted jws without cty",
+			response: func(t *testing.T) []byte {
+				jws, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, claims)
+				assert.NoError(t, err)
+				token, err := jwx.Encode(nil, encrypt, jws)
+				assert.NoError(t, err)
+				return token
+			},
+		},
+		{
+			name: "encrypted jws with unsigned payload",
+			response: func(t *testing.T) []byte {
+				jws, err := jwx.EncodeToString(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), nil, claims)
+				assert.NoError(t, err)
+				forged := jws[:strings.LastIndexByte(jws, '.')+1] + "c2lnbmF0dXJl"
+				token, err := jwx.Encode(nil, encrypt, forged)
+				assert.NoError(t, err)
+				return token
+			},
+			invalid: true,
+		},
+		{
+			name: "encrypted with other content encryption",
+			response: func(t *testing.T) []byte {
+				token, err := jwx.Encode(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), jwx.EncryptionKeyByAlg(jwx.RSA_OAEP_256, jwx.A128CBC_HS256, clientJwks.ToPublic()), claims)
+				assert.NoError(t, err)
+				return token
+			},
+			invalid: true,
+			expect:  tigasdk.ErrInvalidUserInfoEncAlg,
+		},
+		{
+			name: "encrypted with other key encryption",
+			response: func(t *testing.T) []byte {
+				token, err := jwx.Encode(jwx.SignatureKeyByAlg(jwx.ES256, tigaJwks), jwx.EncryptionKeyByAlg(jwx.ECDH_ES, jwx.A256GCM, clientJwks.ToPublic()), claims)
+				assert.NoError(t, err)
+				return token
+			},
+			invalid: true,
+			expect:  tigasdk.ErrInvalidUserInfoEncAlg,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			response = c.response
+
+			userInfo, err := sdk.UserInfo(context.Background(), "access-token", nil)
+			if c.invalid {
+				if c.expect != nil {
+					assert.Equal(t, c.expect, err)
+				} else {
+					assert.Error(t, err)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, "user", userInfo.Subject)
+			}
+		})
+	}
+}
+
+func TestSDK_UserInfo_DPoP(t *testing.T) {
+	var (
+		clientKey = jwx.GenerateSignatureKey("", jwx.ES256, 0)
+		requests  int
+		issuer    string
+		srv       = newTigaServer(jwx.NewKeySet(), map[string]http.HandlerFunc{
+			"/userinfo": func(rw http.ResponseWriter, r *http.Request) {
+				requests++
+				assert.Equal(t, "DPoP access-token", r.Header.Get("Authorization"))
+
+				var proof struct {
+					Method          string `json:"htm"`
+					URI             string `json:"htu"`
+					AccessTokenHash string `json:"ath"`
+					Nonce           string `json:"nonce"`
+				}
+				_, err := jwx.DecodeWithJwk(r.Header.Get("DPoP"), &proof)
+				if !assert.NoError(t, err) {
+					rw.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				ath := sha256.Sum256([]byte("access-token"))
+				assert.Equal(t, http.MethodGet, proof.Method)
+				assert.Equal(t, issuer+"/userinfo", proof.URI)
+				assert.Equal(t, base64.RawURLEncoding.EncodeToString(ath[:]), proof.AccessTokenHash)
+
+				if proof.Nonce != "server-nonce" {
+					rw.Header().Set("DPoP-Nonce", "server-nonce")
+					rw.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce", error_description="nonce is required"`)
+					rw.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				rw.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(rw).Encode(map[string]interface{}{"sub": "user"})
+			},
+		})
+	)
+	defer srv.Close()
+	issuer = srv.URL
+
+	sdk, err := tigasdk.NewWithContext(context.Background(),
+		tigasdk.WithServiceBaseURL(srv.URL),
+		tigasdk.WithPublicClient("client"),
+		tigasdk.WithDPoP(clientKey),
+	)
+	assert.NoError(t, err)
+
+	userInfo, err := sdk.UserInfo(context.Background(), "access-token", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user", userInfo.Subject)
+	}
+	assert.Equal(t, 2, requests)
+}